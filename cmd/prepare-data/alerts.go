@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	alertMemberLoss  = "member-loss"
+	alertNewTeam     = "new-team"
+	alertRemovedTeam = "removed-team"
+)
+
+// AlertRule is checked against the teams of two consecutive snapshots or
+// watch mode polls.
+type AlertRule struct {
+	Name string `yaml:"name"`
+	// Kind is member-loss, new-team or removed-team.
+	Kind string `yaml:"kind"`
+	// Threshold is the fraction of members a team has to lose for
+	// member-loss, 0.3 if unset.
+	Threshold float64 `yaml:"threshold"`
+	// MinMembers ignores teams that had fewer members for member-loss.
+	MinMembers int `yaml:"min_members"`
+	// Privacy restricts the rule to teams with this privacy level.
+	Privacy string `yaml:"privacy"`
+}
+
+type alertConfig struct {
+	// Webhook receives a Slack compatible message for every run with alerts.
+	Webhook string      `yaml:"webhook"`
+	Rules   []AlertRule `yaml:"rules"`
+}
+
+type Alert struct {
+	Rule    string `json:"rule"`
+	Team    string `json:"team"`
+	Message string `json:"message"`
+}
+
+func (r AlertRule) validate() error {
+	switch r.Kind {
+	case alertMemberLoss, alertNewTeam, alertRemovedTeam:
+	default:
+		return fmt.Errorf("Unknown alert kind '%s' of rule '%s', expected one of %s, %s or %s", r.Kind, r.Name, alertMemberLoss, alertNewTeam, alertRemovedTeam)
+	}
+	if r.Threshold < 0 || r.Threshold > 1 {
+		return fmt.Errorf("Threshold of alert rule '%s' must be between 0 and 1, got %v", r.Name, r.Threshold)
+	}
+	if r.Privacy != "" {
+		err := privacyFlag(&r.Privacy)(r.Privacy)
+		if err != nil {
+			return fmt.Errorf("Invalid privacy of alert rule '%s': %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r AlertRule) matchesPrivacy(team Team) bool {
+	return r.Privacy == "" || r.Privacy == privacyAll || team.Privacy == r.Privacy
+}
+
+func (r AlertRule) check(before, after []Team) []Alert {
+	alerts := []Alert{}
+	name := r.Name
+	if name == "" {
+		name = r.Kind
+	}
+
+	beforeByName := map[string]Team{}
+	for _, team := range before {
+		beforeByName[team.Name] = team
+	}
+	afterByName := map[string]Team{}
+	for _, team := range after {
+		afterByName[team.Name] = team
+	}
+
+	switch r.Kind {
+	case alertMemberLoss:
+		threshold := r.Threshold
+		if threshold == 0 {
+			threshold = 0.3
+		}
+		for _, oldTeam := range before {
+			newTeam, ok := afterByName[oldTeam.Name]
+			if !ok || !r.matchesPrivacy(newTeam) || len(oldTeam.Members) == 0 || len(oldTeam.Members) < r.MinMembers {
+				continue
+			}
+			lost := 0
+			for _, member := range oldTeam.Members {
+				if !contains(newTeam.Members, member) {
+					lost++
+				}
+			}
+			if float64(lost)/float64(len(oldTeam.Members)) > threshold {
+				alerts = append(alerts, Alert{Rule: name, Team: oldTeam.Name, Message: fmt.Sprintf("%s lost %d of %d members", oldTeam.Name, lost, len(oldTeam.Members))})
+			}
+		}
+	case alertNewTeam:
+		for _, team := range after {
+			if _, ok := beforeByName[team.Name]; !ok && r.matchesPrivacy(team) {
+				alerts = append(alerts, Alert{Rule: name, Team: team.Name, Message: fmt.Sprintf("new %s team %s with %d members", team.Privacy, team.Name, len(team.Members))})
+			}
+		}
+	case alertRemovedTeam:
+		for _, team := range before {
+			if _, ok := afterByName[team.Name]; !ok && r.matchesPrivacy(team) {
+				alerts = append(alerts, Alert{Rule: name, Team: team.Name, Message: fmt.Sprintf("team %s with %d members was removed", team.Name, len(team.Members))})
+			}
+		}
+	}
+
+	return alerts
+}
+
+// check runs the rules, following renames so that a renamed team isn't
+// reported as removed and new.
+func (c *alertConfig) check(before, after []Team) []Alert {
+	alerts := []Alert{}
+	if c == nil {
+		return alerts
+	}
+	before = renamedTeams(before, detectRenames(before, after))
+	for _, rule := range c.Rules {
+		alerts = append(alerts, rule.check(before, after)...)
+	}
+	return alerts
+}
+
+// notify logs the alerts and posts them to the webhook, if configured.
+func (c *alertConfig) notify(ctx context.Context, org string, alerts []Alert) error {
+	lines := []string{}
+	for _, alert := range alerts {
+		line := fmt.Sprintf("[%s] %s", alert.Rule, alert.Message)
+		log.Printf("alert: %s\n", line)
+		lines = append(lines, line)
+	}
+
+	if c == nil || c.Webhook == "" || len(alerts) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"text":   fmt.Sprintf("org-vis alerts for %s:\n%s", org, strings.Join(lines, "\n")),
+		"alerts": alerts,
+	})
+	if err != nil {
+		return fmt.Errorf("Error marshaling alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Error constructing alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error sending alerts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Error sending alerts: webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type APITeam struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Role string `json:"role"`
+}
+
+type APIMember struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// attachMaintainers records which members are maintainers, so the API can
+// filter by role without asking GitHub on every request.
+func attachMaintainers(ctx context.Context, org string, teams []Team) error {
+	return forEachTeam(ctx, teams, func(ctx context.Context, i int) error {
+		maintainers, err := fetchTeamMembers(ctx, org, teams[i].Slug, roleMaintainer)
+		if err != nil {
+			return fmt.Errorf("Error fetching maintainers for slug %s: %w", teams[i].Slug, err)
+		}
+		teams[i].Maintainers = maintainers
+		return nil
+	})
+}
+
+func memberRole(team Team, login string) string {
+	if contains(team.Maintainers, login) {
+		return roleMaintainer
+	}
+	return roleMember
+}
+
+func roleMatches(filter, role string) bool {
+	return filter == roleAll || filter == role
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeAPIJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// serveAPI answers
+//
+//	/api/members/{login}/teams
+//	/api/teams/{slug}/members
+//
+// from the teams of the last refresh. Both accept ?role=maintainer|member|all.
+// POST /api/refresh?team={slug} is handled by serveRefresh.
+func serveAPI(w http.ResponseWriter, r *http.Request, teams []Team) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	if teams == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "data is not available yet")
+		return
+	}
+
+	role := r.URL.Query().Get("role")
+	if role == "" {
+		role = roleAll
+	}
+	if role != roleAll && role != roleMaintainer && role != roleMember {
+		writeAPIError(w, http.StatusBadRequest, "expected role %s, %s or %s, got '%s'", roleMaintainer, roleMember, roleAll, role)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+	if len(parts) != 3 {
+		writeAPIError(w, http.StatusNotFound, "unknown endpoint %s", r.URL.Path)
+		return
+	}
+
+	switch {
+	case parts[0] == "members" && parts[2] == "teams":
+		found := false
+		result := []APITeam{}
+		for _, team := range teams {
+			login, ok := memberLogin(team, parts[1])
+			if !ok {
+				continue
+			}
+			found = true
+			teamRole := memberRole(team, login)
+			if roleMatches(role, teamRole) {
+				result = append(result, APITeam{Name: team.Name, Slug: team.Slug, Role: teamRole})
+			}
+		}
+		if !found {
+			writeAPIError(w, http.StatusNotFound, "%s is not a member of any team", parts[1])
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, result)
+
+	case parts[0] == "teams" && parts[2] == "members":
+		for _, team := range teams {
+			if team.Slug != parts[1] {
+				continue
+			}
+			result := []APIMember{}
+			for _, member := range team.Members {
+				memberTeamRole := memberRole(team, member)
+				if roleMatches(role, memberTeamRole) {
+					result = append(result, APIMember{Login: member, Role: memberTeamRole})
+				}
+			}
+			writeAPIJSON(w, http.StatusOK, result)
+			return
+		}
+		writeAPIError(w, http.StatusNotFound, "unknown team %s", parts[1])
+
+	default:
+		writeAPIError(w, http.StatusNotFound, "unknown endpoint %s", r.URL.Path)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Badge is a shields.io endpoint, see https://shields.io/badges/endpoint-badge.
+// Served from the outputs, it shows the size of the org in READMEs and on
+// dashboards.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// encodeBadge counts all teams, the teams of every type that isn't primary
+// and the distinct members, like "82 teams / 14 SIGs / 310 members". Type
+// keys are abbreviations, so they are upper-cased.
+func encodeBadge(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	byType := map[string]int{}
+	members := map[string]bool{}
+	for _, team := range teams {
+		if teamType, ok := opts.taxonomy.typeOf(team.Name); ok {
+			byType[teamType.Key]++
+		}
+		for _, member := range team.Members {
+			members[member] = true
+		}
+	}
+
+	parts := []string{fmt.Sprintf("%d teams", len(teams))}
+	for _, teamType := range opts.taxonomy {
+		if !teamType.Primary && byType[teamType.Key] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %ss", byType[teamType.Key], strings.ToUpper(teamType.Key)))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("%d members", len(members)))
+
+	return marshalIndented(Badge{SchemaVersion: 1, Label: opts.org, Message: strings.Join(parts, " / "), Color: "blue"})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// responseCache stores GitHub API responses on disk keyed by URL.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+	// refresh ignores cached entries but still stores new responses, which
+	// is what the warm command uses to renew the whole cache.
+	refresh bool
+}
+
+type cacheEntry struct {
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Body      []byte    `json:"body"`
+
+	// Validators of the response, only stored by the etag cache.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Link         string `json:"link,omitempty"`
+}
+
+// cache is used by fetchJSON and fetchAllJSON when set.
+var cache *responseCache
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ".org-vis-cache"
+	}
+	return filepath.Join(dir, "org-vis")
+}
+
+func (c *responseCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *responseCache) get(url string) ([]byte, bool) {
+	if c.refresh {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil || entry.URL != url || time.Since(entry.FetchedAt) > c.ttl {
+		return nil, false
+	}
+
+	return entry.Body, true
+}
+
+func (c *responseCache) put(url string, body []byte) error {
+	return writeCacheEntry(c.dir, c.path(url), cacheEntry{URL: url, FetchedAt: time.Now().UTC(), Body: body})
+}
+
+func writeCacheEntry(dir, path string, entry cacheEntry) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("Error creating cache directory '%s': %w", dir, err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("Error marshaling cache entry: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("Error writing cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// etagCache stores single REST responses with their ETag and Last-Modified
+// validators, so that later runs can revalidate them with conditional
+// requests instead of downloading them again. Unlike responseCache it never
+// returns stale data, so it needs no TTL.
+type etagCache struct {
+	dir string
+}
+
+func (c *etagCache) path(url string) string {
+	sum := sha256.Sum256([]byte("etag " + url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *etagCache) Get(url string) (github.CachedResponse, bool) {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return github.CachedResponse{}, false
+	}
+
+	var entry cacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil || entry.URL != url {
+		return github.CachedResponse{}, false
+	}
+
+	return github.CachedResponse{Body: entry.Body, ETag: entry.ETag, LastModified: entry.LastModified, Link: entry.Link}, true
+}
+
+func (c *etagCache) Put(url string, response github.CachedResponse) {
+	err := writeCacheEntry(c.dir, c.path(url), cacheEntry{
+		URL:          url,
+		FetchedAt:    time.Now().UTC(),
+		Body:         response.Body,
+		ETag:         response.ETag,
+		LastModified: response.LastModified,
+		Link:         response.Link,
+	})
+	if err != nil {
+		log.Printf("Error caching response for url '%s': %v\n", url, err)
+	}
+}
+
+type noCacheKey struct{}
+
+// withoutCache makes fetches with the returned context bypass the response
+// cache. Their responses are still stored for later fetches.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
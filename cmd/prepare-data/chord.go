@@ -0,0 +1,23 @@
+package main
+
+import "context"
+
+// Chord is the input d3.chord expects: a square matrix of flows and the
+// names labelling its rows and columns.
+type Chord struct {
+	Matrix [][]int  `json:"matrix"`
+	Names  []string `json:"names"`
+}
+
+func toChord(teams []Team) *Chord {
+	names := []string{}
+	for _, team := range teams {
+		names = append(names, team.Name)
+	}
+
+	return &Chord{Matrix: overlapMatrix(teams), Names: names}
+}
+
+func encodeChord(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	return marshalIndented(toChord(teams))
+}
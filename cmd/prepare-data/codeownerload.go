@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CodeownerLoad is how many repositories and CODEOWNERS paths a person is
+// named in individually, not through a team.
+type CodeownerLoad struct {
+	Login string
+	Repos []string
+	Paths int
+}
+
+func codeownerLoads(repos []lintRepository) []CodeownerLoad {
+	byLogin := map[string]*CodeownerLoad{}
+
+	for _, repo := range repos {
+		for _, rule := range repo.CodeownersRules {
+			for _, owner := range rule.Owners {
+				if !strings.HasPrefix(owner, "@") || strings.Contains(owner, "/") {
+					continue
+				}
+				login := strings.ToLower(owner[1:])
+				load, ok := byLogin[login]
+				if !ok {
+					load = &CodeownerLoad{Login: login, Repos: []string{}}
+					byLogin[login] = load
+				}
+				load.Paths++
+				if !contains(load.Repos, repo.Name) {
+					load.Repos = append(load.Repos, repo.Name)
+				}
+			}
+		}
+	}
+
+	loads := []CodeownerLoad{}
+	for _, load := range byLogin {
+		loads = append(loads, *load)
+	}
+	sort.Slice(loads, func(i, j int) bool {
+		if loads[i].Paths != loads[j].Paths {
+			return loads[i].Paths > loads[j].Paths
+		}
+		return loads[i].Login < loads[j].Login
+	})
+
+	return loads
+}
+
+// codeownerLoadLimit is the number of paths above which a person's load is
+// an outlier: two standard deviations above the mean.
+func codeownerLoadLimit(loads []CodeownerLoad) float64 {
+	if len(loads) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, load := range loads {
+		total += float64(load.Paths)
+	}
+	mean := total / float64(len(loads))
+
+	variance := 0.0
+	for _, load := range loads {
+		variance += math.Pow(float64(load.Paths)-mean, 2)
+	}
+	stddev := math.Sqrt(variance / float64(len(loads)))
+
+	return mean + 2*stddev
+}
+
+func checkCodeownerLoad(ctx context.Context, in *lintInput) ([]Finding, error) {
+	repos, err := in.repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loads := codeownerLoads(repos)
+	limit := codeownerLoadLimit(loads)
+
+	findings := []Finding{}
+	for _, load := range loads {
+		if float64(load.Paths) > limit {
+			findings = append(findings, Finding{
+				Rule:    "codeowner-load",
+				Message: fmt.Sprintf("@%s is individually codeowner of %d paths in %d repositories, more than %.1f", load.Login, load.Paths, len(load.Repos), limit),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+func writeCodeownerLoadReport(ctx context.Context, path string, in *lintInput) error {
+	repos, err := in.repositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	loads := codeownerLoads(repos)
+	limit := codeownerLoadLimit(loads)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err = w.Write([]string{"login", "paths", "repositories", "outlier", "repository_names"})
+	if err != nil {
+		return fmt.Errorf("Error writing codeowner load report header: %w", err)
+	}
+
+	for _, load := range loads {
+		outlier := fmt.Sprint(float64(load.Paths) > limit)
+		err = w.Write([]string{load.Login, fmt.Sprint(load.Paths), fmt.Sprint(len(load.Repos)), outlier, strings.Join(load.Repos, " ")})
+		if err != nil {
+			return fmt.Errorf("Error writing codeowner load report row: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("Error writing codeowner load report: %w", err)
+	}
+
+	log.Printf("writing codeowner load report to %s\n", path)
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing codeowner load report file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+)
+
+type CodeownersRule struct {
+	Line    int
+	Pattern string
+	Owners  []string
+}
+
+func parseCodeowners(content string) []CodeownersRule {
+	rules := []CodeownersRule{}
+
+	for i, line := range strings.Split(content, "\n") {
+		if j := strings.Index(line, "#"); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		rules = append(rules, CodeownersRule{Line: i + 1, Pattern: fields[0], Owners: fields[1:]})
+	}
+
+	return rules
+}
+
+// codeownersTeam splits an owner of the form @org/team-slug into its
+// organization and team slug. ok is false for users and email addresses.
+func codeownersTeam(owner string) (org string, slug string, ok bool) {
+	if !strings.HasPrefix(owner, "@") {
+		return "", "", false
+	}
+	parts := strings.SplitN(owner[1:], "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type OrgStructure struct {
+	Org                    string         `json:"org"`
+	Teams                  int            `json:"teams"`
+	TeamsByType            map[string]int `json:"teams_by_type"`
+	Members                int            `json:"members"`
+	MinTeamSize            int            `json:"min_team_size"`
+	MedianTeamSize         float64        `json:"median_team_size"`
+	MeanTeamSize           float64        `json:"mean_team_size"`
+	MaxTeamSize            int            `json:"max_team_size"`
+	TeamsPerMember         float64        `json:"teams_per_member"`
+	MembersInMultipleTeams int            `json:"members_in_multiple_teams"`
+	OverlappingPairs       int            `json:"overlapping_pairs"`
+	OverlapDensity         float64        `json:"overlap_density"`
+	MeanJaccard            float64        `json:"mean_jaccard"`
+}
+
+func teamTypeOf(name string) string {
+	teamType, ok := defaultTaxonomy.typeOf(name)
+	if !ok {
+		return "other"
+	}
+	return teamType.Key
+}
+
+func orgStructure(org string, teams []Team) OrgStructure {
+	s := OrgStructure{Org: org, Teams: len(teams), TeamsByType: map[string]int{}}
+
+	sizes := []int{}
+	memberships := map[string]int{}
+
+	for _, team := range teams {
+		s.TeamsByType[teamTypeOf(team.Name)]++
+		sizes = append(sizes, len(team.Members))
+		for _, member := range team.Members {
+			memberships[member]++
+		}
+	}
+
+	s.Members = len(memberships)
+	for _, n := range memberships {
+		if n > 1 {
+			s.MembersInMultipleTeams++
+		}
+	}
+
+	if len(sizes) > 0 {
+		sort.Ints(sizes)
+		total := 0
+		for _, size := range sizes {
+			total += size
+		}
+		s.MinTeamSize = sizes[0]
+		s.MaxTeamSize = sizes[len(sizes)-1]
+		s.MeanTeamSize = float64(total) / float64(len(sizes))
+		if len(sizes)%2 == 1 {
+			s.MedianTeamSize = float64(sizes[len(sizes)/2])
+		} else {
+			s.MedianTeamSize = float64(sizes[len(sizes)/2-1]+sizes[len(sizes)/2]) / 2
+		}
+		if s.Members > 0 {
+			s.TeamsPerMember = float64(total) / float64(s.Members)
+		}
+	}
+
+	pairs := 0
+	jaccardSum := 0.0
+	for i := range teams {
+		for j := i + 1; j < len(teams); j++ {
+			pairs++
+			if len(sharedMembers(teams[i], teams[j])) > 0 {
+				s.OverlappingPairs++
+			}
+			jaccardSum += jaccard(teams[i], teams[j])
+		}
+	}
+	if pairs > 0 {
+		s.OverlapDensity = float64(s.OverlappingPairs) / float64(pairs)
+		s.MeanJaccard = jaccardSum / float64(pairs)
+	}
+
+	return s
+}
+
+func fetchAllTeamsWithMembers(ctx context.Context, org string) ([]Team, error) {
+	teams, err := fetchAllTeams(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachTeam(ctx, teams, func(ctx context.Context, i int) error {
+		members, err := fetchTeamMembers(ctx, org, teams[i].Slug, roleAll)
+		if err != nil {
+			return fmt.Errorf("Error fetching team members for slug %s: %w", teams[i].Slug, err)
+		}
+		teams[i].Members = members
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+func printComparison(out io.Writer, structures []OrgStructure) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	row := func(label string, value func(s OrgStructure) string) {
+		cells := []string{label}
+		for _, s := range structures {
+			cells = append(cells, value(s))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	types := []string{}
+	for _, s := range structures {
+		for typeStr := range s.TeamsByType {
+			if !contains(types, typeStr) {
+				types = append(types, typeStr)
+			}
+		}
+	}
+	sort.Strings(types)
+
+	row("", func(s OrgStructure) string { return s.Org })
+	row("teams", func(s OrgStructure) string { return fmt.Sprint(s.Teams) })
+	for _, typeStr := range types {
+		typeStr := typeStr
+		row("  "+typeStr, func(s OrgStructure) string { return fmt.Sprint(s.TeamsByType[typeStr]) })
+	}
+	row("members", func(s OrgStructure) string { return fmt.Sprint(s.Members) })
+	row("team size (min/median/mean/max)", func(s OrgStructure) string {
+		return fmt.Sprintf("%d/%.1f/%.1f/%d", s.MinTeamSize, s.MedianTeamSize, s.MeanTeamSize, s.MaxTeamSize)
+	})
+	row("teams per member", func(s OrgStructure) string { return fmt.Sprintf("%.2f", s.TeamsPerMember) })
+	row("members in multiple teams", func(s OrgStructure) string { return fmt.Sprint(s.MembersInMultipleTeams) })
+	row("overlapping team pairs", func(s OrgStructure) string { return fmt.Sprint(s.OverlappingPairs) })
+	row("overlap density", func(s OrgStructure) string { return fmt.Sprintf("%.3f", s.OverlapDensity) })
+	row("mean jaccard", func(s OrgStructure) string { return fmt.Sprintf("%.3f", s.MeanJaccard) })
+
+	w.Flush()
+}
+
+func runCompare(ctx context.Context, args []string) error {
+	orgs := []string{}
+
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	flags.Func("org", "organization to compare, must be given at least twice", func(value string) error {
+		orgs = append(orgs, value)
+		return nil
+	})
+	allTeams := flags.Bool("all-teams", false, "include teams that don't follow the sig-/team-/wg- naming convention")
+	jsonOutput := flags.Bool("json", false, "print the comparison as json")
+	credentialsPath := flags.String("org-credentials", "", "YAML file mapping organizations to the environment variable of their token or to a GitHub App installation, for organizations GITHUB_TOKEN can't see")
+	flags.Parse(args)
+
+	if len(orgs) < 2 {
+		return fmt.Errorf("At least two organizations are required, got %d", len(orgs))
+	}
+
+	credentials := orgCredentials{}
+	if *credentialsPath != "" {
+		var err error
+		credentials, err = loadOrgCredentials(*credentialsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	structures := []OrgStructure{}
+
+	for _, org := range orgs {
+		orgCtx, err := credentials.context(ctx, org)
+		if err != nil {
+			return err
+		}
+
+		var teams []Team
+		if *allTeams {
+			teams, err = fetchAllTeamsWithMembers(orgCtx, org)
+		} else {
+			teams, err = fetchTeams(orgCtx, org, defaultTeamFilter)
+		}
+		if err != nil {
+			return fmt.Errorf("Error fetching teams of %s: %w", org, err)
+		}
+		structures = append(structures, orgStructure(org, teams))
+	}
+
+	if *jsonOutput {
+		data, err := marshalIndented(structures)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printComparison(os.Stdout, structures)
+
+	return nil
+}
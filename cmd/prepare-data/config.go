@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath is used as --config when it exists in the working
+// directory.
+const defaultConfigPath = "org-vis.yaml"
+
+type Config struct {
+	Org      string        `yaml:"org"`
+	Types    []TeamType    `yaml:"types"`
+	Interval time.Duration `yaml:"interval"`
+	Formats  []string      `yaml:"formats"`
+	Filters  *teamFilter   `yaml:"filters"`
+	// Output is the output path template, like --output. Outputs overrides
+	// it for single formats.
+	Output  string            `yaml:"output"`
+	Outputs map[string]string `yaml:"outputs"`
+	Alerts  *alertConfig      `yaml:"alerts"`
+	// Extra declares nodes and edges that don't exist in GitHub.
+	Extra *extraGraph `yaml:"extra"`
+	// Normalize rewrites team names before their type is detected.
+	Normalize nameRules `yaml:"normalize"`
+
+	taxonomy taxonomy
+}
+
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading config file '%s': %w", path, err)
+	}
+
+	var config Config
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&config)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing config file '%s': %w", path, err)
+	}
+
+	if config.Filters != nil {
+		if config.Filters.Privacy != "" {
+			err := privacyFlag(&config.Filters.Privacy)(config.Filters.Privacy)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid filters.privacy in config file '%s': %w", path, err)
+			}
+		}
+		if config.Filters.Role != "" {
+			err := roleFlag(&config.Filters.Role)(config.Filters.Role)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid filters.role in config file '%s': %w", path, err)
+			}
+		}
+	}
+
+	if config.Interval < 0 {
+		return nil, fmt.Errorf("Invalid interval in config file '%s': %s is negative", path, config.Interval)
+	}
+
+	if config.Alerts != nil {
+		if config.Alerts.Webhook != "" {
+			webhook, err := url.Parse(config.Alerts.Webhook)
+			if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+				return nil, fmt.Errorf("Invalid alerts.webhook in config file '%s': expected an http or https URL", path)
+			}
+		}
+		for _, rule := range config.Alerts.Rules {
+			err := rule.validate()
+			if err != nil {
+				return nil, fmt.Errorf("Invalid alerts in config file '%s': %w", path, err)
+			}
+		}
+	}
+
+	if config.Extra != nil {
+		err := config.Extra.validate(config.Org)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid extra in config file '%s': %w", path, err)
+		}
+	}
+
+	err = config.Normalize.validate()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid normalize in config file '%s': %w", path, err)
+	}
+
+	for name := range config.Outputs {
+		if _, err := parseFormats(name); err != nil {
+			return nil, fmt.Errorf("Invalid outputs in config file '%s': %w", path, err)
+		}
+	}
+
+	config.taxonomy, err = defaultTaxonomy.with(config.Types)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid types in config file '%s': %w", path, err)
+	}
+	if config.Filters != nil {
+		for _, prefix := range config.Filters.Prefixes {
+			if _, ok := config.taxonomy.typeOf(prefix); !ok {
+				return nil, fmt.Errorf("Team prefix '%s' in config file '%s' doesn't match any team type", prefix, path)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+// mergeFilters returns base with the keys the filters block of a config
+// file sets. Left out keys keep their value, like the default excluded
+// suffixes.
+func mergeFilters(base, config teamFilter) teamFilter {
+	if config.Prefixes != nil {
+		base.Prefixes = config.Prefixes
+	}
+	if config.ExcludeSuffixes != nil {
+		base.ExcludeSuffixes = config.ExcludeSuffixes
+	}
+	if config.Exclude != nil {
+		base.Exclude = config.Exclude
+	}
+	if config.Privacy != "" {
+		base.Privacy = config.Privacy
+	}
+	if config.Role != "" {
+		base.Role = config.Role
+	}
+	if config.Allowlist != "" {
+		base.Allowlist = config.Allowlist
+	}
+	if config.Denylist != "" {
+		base.Denylist = config.Denylist
+	}
+	return base
+}
+
+// applyConfigFilters sets the team filter of opts from the config file,
+// keeping the filter flags that were set explicitly.
+func applyConfigFilters(opts *options, config *Config) {
+	if config.Filters != nil {
+		flagFilter := opts.filter
+		opts.filter = mergeFilters(opts.filter, *config.Filters)
+		if opts.setFlags["privacy"] {
+			opts.filter.Privacy = flagFilter.Privacy
+		}
+		if opts.setFlags["roles"] {
+			opts.filter.Role = flagFilter.Role
+		}
+		if opts.setFlags["allowlist"] {
+			opts.filter.Allowlist = flagFilter.Allowlist
+		}
+		if opts.setFlags["denylist"] {
+			opts.filter.Denylist = flagFilter.Denylist
+		}
+	}
+	if config.Filters == nil || len(config.Filters.Prefixes) == 0 {
+		// Without explicit prefixes every known team type is included.
+		opts.filter.Prefixes = opts.taxonomy.prefixes()
+	}
+	opts.filter.names = config.Normalize
+}
+
+// configTeamFilter returns the team filter a run with the config file at
+// path and no filter flags uses. An empty path is the default filter.
+func configTeamFilter(path string) (teamFilter, error) {
+	opts := &options{filter: defaultTeamFilter, taxonomy: defaultTaxonomy, setFlags: map[string]bool{}}
+	if path != "" {
+		config, err := loadConfig(path)
+		if err != nil {
+			return teamFilter{}, err
+		}
+		opts.taxonomy = config.taxonomy
+		applyConfigFilters(opts, config)
+	}
+
+	err := opts.filter.loadTeamLists()
+	if err != nil {
+		return teamFilter{}, err
+	}
+	return opts.filter, nil
+}
+
+// resolveOptions applies the config file, if any, on top of the options
+// given on the command line. Flags that were set explicitly take precedence
+// over the config file. base is not modified, so this can be called again
+// to reload the configuration.
+func resolveOptions(base *options) (*options, error) {
+	opts := *base
+
+	if opts.configPath != "" {
+		config, err := loadConfig(opts.configPath)
+		if err != nil {
+			return nil, err
+		}
+
+		// The org from the environment takes precedence over the config
+		// file, like flags do.
+		if config.Org != "" && !opts.setFlags["org"] && os.Getenv("ORG_VIS_ORG") == "" {
+			opts.org = config.Org
+		}
+		if config.Output != "" && !opts.setFlags["output"] {
+			opts.output = config.Output
+		}
+		if !opts.setFlags["output"] {
+			opts.outputPaths = config.Outputs
+		}
+		opts.alerts = config.Alerts
+		opts.extra = config.Extra
+		if config.Interval != 0 && !opts.setFlags["interval"] {
+			opts.interval = config.Interval
+		}
+		if len(config.Formats) > 0 && !opts.setFlags["format"] {
+			opts.formatList = strings.Join(config.Formats, ",")
+		}
+		opts.taxonomy = config.taxonomy
+
+		applyConfigFilters(&opts, config)
+	}
+
+	// The lists are read on every resolve, so watch mode picks up changes
+	// on reload.
+	err := opts.filter.loadTeamLists()
+	if err != nil {
+		return nil, err
+	}
+
+	opts.formats, err = parseFormats(opts.formatList)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing formats: %w", err)
+	}
+
+	if opts.output == stdoutPath {
+		if opts.check {
+			return nil, fmt.Errorf("--check can't be used with --output -")
+		}
+		if opts.summaryJSON {
+			return nil, fmt.Errorf("--summary-json can't be used with --output -, both write to stdout")
+		}
+	}
+
+	err = opts.encryption.validate()
+	if err != nil {
+		return nil, err
+	}
+	if opts.encryption.enabled() && opts.check {
+		return nil, fmt.Errorf("--encrypt can't be used with --check, encrypted outputs can't be compared")
+	}
+
+	if opts.output == stdoutPath && (opts.provenance || opts.signingKeyPath != "") {
+		return nil, fmt.Errorf("--provenance and --signing-key can't be used with --output -")
+	}
+	if opts.signingKeyPath != "" {
+		opts.signingKey, err = loadSigningKey(opts.signingKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.source != "" && (opts.projects || opts.discussions || opts.teamSync || opts.repoTopology || opts.ownershipHealth || opts.reviewLatency) {
+		return nil, fmt.Errorf("--source can't be used with --projects, --discussions, --team-sync, --repo-topology, --ownership-health or --review-latency, they need the GitHub API")
+	}
+
+	if opts.whatIfPath != "" {
+		if opts.snapshotDir != "" {
+			return nil, fmt.Errorf("--what-if can't be used with --snapshot-dir, hypothetical teams aren't stored")
+		}
+		opts.whatIf, err = loadWhatIfOverlay(opts.whatIfPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.pseudonymize {
+		if opts.identityMapPath != "" {
+			return nil, fmt.Errorf("--pseudonymize can't be used with --identity-map, pseudonyms aren't in the directory")
+		}
+		path := opts.pseudonymSaltPath
+		if path == "" {
+			path = filepath.Join(opts.cacheDir, pseudonymSaltFile)
+		}
+		opts.pseudonyms, err = loadPseudonyms(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.identityMapPath != "" {
+		opts.identities, err = loadIdentityMap(opts.identityMapPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.people {
+		if opts.privacyMode {
+			return nil, fmt.Errorf("--people can't be used with --privacy-mode, the person nodes are named by login")
+		}
+		if opts.pruneImpliedOverlaps {
+			return nil, fmt.Errorf("--prune-implied-overlaps can't be used with --people, there are no overlap edges")
+		}
+		if _, ok := opts.taxonomy.byKey(personType); ok {
+			return nil, fmt.Errorf("--people can't be used with a team type with the key '%s'", personType)
+		}
+	}
+
+	if opts.onCallPath != "" {
+		opts.onCall, err = loadOnCallSchedule(opts.onCallPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.queryExpression != "" {
+		opts.query, err = jmespath.Compile(opts.queryExpression)
+		if err != nil {
+			return nil, fmt.Errorf("Error compiling query '%s': %w", opts.queryExpression, err)
+		}
+	}
+
+	// Last, so a failed reload doesn't leave a store open.
+	if opts.snapshotDir != "" {
+		opts.snapshots, err = openSnapshotStore(opts.snapshotDir, opts.perms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &opts, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func resolveConfig(t *testing.T, config string, args ...string) *options {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "org-vis.yaml")
+	err := os.WriteFile(path, []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := parseOptions(flag.NewFlagSet("test", flag.ContinueOnError), append([]string{"--config", path}, args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := resolveOptions(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func TestPartialFiltersKeepDefaults(t *testing.T) {
+	opts := resolveConfig(t, "filters:\n  privacy: closed\n")
+
+	if opts.filter.Privacy != privacyClosed {
+		t.Errorf("expected privacy from the config, got '%s'", opts.filter.Privacy)
+	}
+	if !reflect.DeepEqual(opts.filter.ExcludeSuffixes, defaultTeamFilter.ExcludeSuffixes) {
+		t.Errorf("expected the default excluded suffixes, got %v", opts.filter.ExcludeSuffixes)
+	}
+	if !reflect.DeepEqual(opts.filter.Prefixes, opts.taxonomy.prefixes()) {
+		t.Errorf("expected the prefixes of the taxonomy, got %v", opts.filter.Prefixes)
+	}
+	if opts.filter.relevant("team-engineers") {
+		t.Errorf("expected team-engineers to stay excluded")
+	}
+}
+
+func TestFiltersOverride(t *testing.T) {
+	cases := map[string]struct {
+		config   string
+		args     []string
+		suffixes []string
+		prefixes []string
+		privacy  string
+	}{
+		"suffixes cleared": {
+			config:   "filters:\n  exclude_suffixes: []\n",
+			suffixes: []string{},
+			privacy:  "",
+		},
+		"prefixes set": {
+			config:   "filters:\n  prefixes: [sig-]\n",
+			suffixes: defaultTeamFilter.ExcludeSuffixes,
+			prefixes: []string{"sig-"},
+		},
+		"flag wins": {
+			config:   "filters:\n  privacy: closed\n",
+			args:     []string{"--privacy", "secret"},
+			suffixes: defaultTeamFilter.ExcludeSuffixes,
+			privacy:  privacySecret,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			opts := resolveConfig(t, c.config, c.args...)
+			if !reflect.DeepEqual(opts.filter.ExcludeSuffixes, c.suffixes) {
+				t.Errorf("expected suffixes %v, got %v", c.suffixes, opts.filter.ExcludeSuffixes)
+			}
+			if c.prefixes != nil && !reflect.DeepEqual(opts.filter.Prefixes, c.prefixes) {
+				t.Errorf("expected prefixes %v, got %v", c.prefixes, opts.filter.Prefixes)
+			}
+			if opts.filter.Privacy != c.privacy {
+				t.Errorf("expected privacy '%s', got '%s'", c.privacy, opts.filter.Privacy)
+			}
+		})
+	}
+}
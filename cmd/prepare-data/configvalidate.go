@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+	"gopkg.in/yaml.v3"
+)
+
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintf(os.Stderr, "Usage: %s config validate [flags]\n", os.Args[0])
+		return fmt.Errorf("Expected a config command: validate")
+	}
+
+	return runConfigValidate(ctx, args[1:])
+}
+
+// runConfigValidate resolves the configuration like a run would, checks
+// everything that can be checked without fetching the organization and
+// prints the effective configuration. It accepts the flags of a run, so
+// their precedence over the config file is shown too.
+func runConfigValidate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("config validate", flag.ExitOnError)
+	base, err := parseOptions(flags, args)
+	if err != nil {
+		return err
+	}
+	err = configureHTTP(base.http)
+	if err != nil {
+		return err
+	}
+	github.APIURL = base.apiURL
+	github.RequestTimeout = base.requestTimeout
+
+	if base.configPath == "" {
+		progress.Printf("no config file, validating the flags only\n")
+	} else {
+		progress.Printf("validating %s\n", base.configPath)
+	}
+
+	// resolveOptions loads the config file strictly, so unknown keys are
+	// refused, and compiles --query and reads the team lists, the what-if
+	// overlay and the identity map.
+	opts, err := resolveOptions(base)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := resolveOutputs(opts, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, o := range outputs {
+		if o.format.name != "template" {
+			continue
+		}
+		if opts.templatePath == "" {
+			return fmt.Errorf("The template format requires --template")
+		}
+		_, err := parseTemplateFile(opts.templatePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.source == "" {
+		credentials, err := github.Credentials(ctx)
+		if err != nil {
+			return fmt.Errorf("Error resolving GitHub credentials: %w", err)
+		}
+		// The rate limit endpoint doesn't count against the rate limit, but
+		// refuses invalid credentials.
+		_, err = github.Get(ctx, github.APIURL+"/rate_limit")
+		if err != nil {
+			return fmt.Errorf("Error checking GitHub credentials (%s): %w", credentials, err)
+		}
+		if github.Anonymous() {
+			log.Printf("%s, requests are sent anonymously\n", credentials)
+		} else {
+			progress.Printf("authenticated with %s\n", credentials)
+		}
+	}
+
+	data, err := yaml.Marshal(effectiveConfig(opts))
+	if err != nil {
+		return fmt.Errorf("Error encoding effective config: %w", err)
+	}
+	fmt.Print(string(data))
+
+	return nil
+}
+
+// effectiveConfig returns the configuration a run with opts uses, in the
+// format of the config file.
+func effectiveConfig(opts *options) Config {
+	formats := []string{}
+	for _, f := range opts.formats {
+		formats = append(formats, f.name)
+	}
+	filter := opts.filter
+
+	config := Config{
+		Org:       opts.org,
+		Types:     opts.taxonomy,
+		Interval:  opts.interval,
+		Formats:   formats,
+		Filters:   &filter,
+		Output:    opts.output,
+		Outputs:   opts.outputPaths,
+		Extra:     opts.extra,
+		Normalize: opts.filter.names,
+	}
+	if opts.alerts != nil {
+		alerts := *opts.alerts
+		alerts.Webhook = redactURL(alerts.Webhook)
+		config.Alerts = &alerts
+	}
+
+	return config
+}
+
+// redactURL leaves out everything but the scheme and host of rawURL, as
+// webhook URLs contain their secret.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
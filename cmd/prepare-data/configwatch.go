@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchConfigFile signals on the returned channel whenever the config file
+// changes. The directory is watched rather than the file itself, since
+// editors and config management commonly replace files by renaming.
+// Bursts of events are coalesced into one signal.
+func watchConfigFile(path string) (<-chan struct{}, func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error creating config file watcher: %w", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		watcher.Close()
+		return nil, nil, fmt.Errorf("Error resolving config file path '%s': %w", path, err)
+	}
+
+	err = watcher.Add(filepath.Dir(absPath))
+	if err != nil {
+		watcher.Close()
+		return nil, nil, fmt.Errorf("Error watching config file '%s': %w", path, err)
+	}
+
+	changed := make(chan struct{}, 1)
+
+	go func() {
+		var debounce <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != absPath {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(500 * time.Millisecond)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Error watching config file: %v\n", err)
+			case <-debounce:
+				debounce = nil
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return changed, func() { watcher.Close() }, nil
+}
+
+// describeOptionChanges lists the settings that differ between two
+// resolved configurations, for logging after a reload.
+func describeOptionChanges(before, after *options) []string {
+	changes := []string{}
+
+	if before.org != after.org {
+		changes = append(changes, fmt.Sprintf("org: %s -> %s", before.org, after.org))
+	}
+	if before.output != after.output || fmt.Sprint(before.outputPaths) != fmt.Sprint(after.outputPaths) {
+		changes = append(changes, "output paths changed")
+	}
+	if before.interval != after.interval {
+		changes = append(changes, fmt.Sprintf("interval: %s -> %s", before.interval, after.interval))
+	}
+
+	list := func(values []string) string {
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+
+	beforeFormats, afterFormats := []string{}, []string{}
+	for _, f := range before.formats {
+		beforeFormats = append(beforeFormats, f.name)
+	}
+	for _, f := range after.formats {
+		afterFormats = append(afterFormats, f.name)
+	}
+	if list(beforeFormats) != list(afterFormats) {
+		changes = append(changes, fmt.Sprintf("formats: %s -> %s", list(beforeFormats), list(afterFormats)))
+	}
+
+	if list(before.filter.Prefixes) != list(after.filter.Prefixes) {
+		changes = append(changes, fmt.Sprintf("filters.prefixes: %s -> %s", list(before.filter.Prefixes), list(after.filter.Prefixes)))
+	}
+	if list(before.filter.ExcludeSuffixes) != list(after.filter.ExcludeSuffixes) {
+		changes = append(changes, fmt.Sprintf("filters.exclude_suffixes: %s -> %s", list(before.filter.ExcludeSuffixes), list(after.filter.ExcludeSuffixes)))
+	}
+	if list(before.filter.Exclude) != list(after.filter.Exclude) {
+		changes = append(changes, fmt.Sprintf("filters.exclude: %s -> %s", list(before.filter.Exclude), list(after.filter.Exclude)))
+	}
+
+	if list(before.filter.allow) != list(after.filter.allow) {
+		changes = append(changes, fmt.Sprintf("allowlist: %s -> %s", list(before.filter.allow), list(after.filter.allow)))
+	}
+	if list(before.filter.deny) != list(after.filter.deny) {
+		changes = append(changes, fmt.Sprintf("denylist: %s -> %s", list(before.filter.deny), list(after.filter.deny)))
+	}
+
+	if !reflect.DeepEqual(before.alerts, after.alerts) {
+		changes = append(changes, "alerts: changed")
+	}
+	if !reflect.DeepEqual(before.extra, after.extra) {
+		changes = append(changes, "extra: changed")
+	}
+
+	if fmt.Sprint(before.filter.names) != fmt.Sprint(after.filter.names) {
+		changes = append(changes, "normalize: changed")
+	}
+
+	if fmt.Sprint(before.taxonomy) != fmt.Sprint(after.taxonomy) {
+		changes = append(changes, fmt.Sprintf("types: %s -> %s", list(before.taxonomy.prefixes()), list(after.taxonomy.prefixes())))
+	}
+	if before.filter.Role != after.filter.Role {
+		changes = append(changes, fmt.Sprintf("filters.role: %q -> %q", before.filter.Role, after.filter.Role))
+	}
+	if before.filter.Privacy != after.filter.Privacy {
+		changes = append(changes, fmt.Sprintf("filters.privacy: %q -> %q", before.filter.Privacy, after.filter.Privacy))
+	}
+
+	return changes
+}
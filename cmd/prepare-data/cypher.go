@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const cypherFormat = "cypher"
+
+// cypherString quotes value as a Cypher string literal.
+func cypherString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// encodeCypher writes Cypher statements for cypher-shell that merge the
+// teams, their members and the memberships into a Neo4j database:
+// (:Person)-[:MEMBER_OF {role}]->(:Team) and (:Team)-[:CHILD_OF]->(:Team).
+// MERGE only adds and updates, so memberships that ended are only dropped
+// when loading into an empty database.
+func encodeCypher(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.privacyMode {
+		return nil, fmt.Errorf("The %s format lists members and can't be used in privacy mode", cypherFormat)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("CREATE CONSTRAINT team_slug IF NOT EXISTS FOR (t:Team) REQUIRE t.slug IS UNIQUE;\n")
+	buf.WriteString("CREATE CONSTRAINT person_login IF NOT EXISTS FOR (p:Person) REQUIRE p.login IS UNIQUE;\n")
+
+	for _, team := range teams {
+		properties := []string{"t.name = " + cypherString(team.Name)}
+		if team.Privacy != "" {
+			properties = append(properties, "t.privacy = "+cypherString(team.Privacy))
+		}
+		if teamType, ok := opts.taxonomy.typeOf(team.Name); ok {
+			properties = append(properties, "t.type = "+cypherString(teamType.Key))
+		}
+		fmt.Fprintf(&buf, "MERGE (t:Team {slug: %s}) SET %s;\n", cypherString(team.Slug), strings.Join(properties, ", "))
+	}
+
+	logins := []string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if !contains(logins, member) {
+				logins = append(logins, member)
+			}
+		}
+	}
+	sort.Strings(logins)
+	for _, login := range logins {
+		fmt.Fprintf(&buf, "MERGE (p:Person {login: %s});\n", cypherString(login))
+	}
+
+	for _, team := range teams {
+		for _, member := range team.Members {
+			fmt.Fprintf(&buf, "MATCH (p:Person {login: %s}), (t:Team {slug: %s}) MERGE (p)-[r:MEMBER_OF]->(t) SET r.role = %s;\n",
+				cypherString(member), cypherString(team.Slug), cypherString(memberRole(team, member)))
+		}
+	}
+
+	for _, team := range teams {
+		if team.Parent == nil {
+			continue
+		}
+		fmt.Fprintf(&buf, "MATCH (c:Team {slug: %s}), (p:Team {slug: %s}) MERGE (c)-[:CHILD_OF]->(p);\n", cypherString(team.Slug), cypherString(team.Parent.Slug))
+	}
+
+	return buf.Bytes(), nil
+}
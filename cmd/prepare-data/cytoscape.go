@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CytoscapeElements is the elements format of Cytoscape.js, which can be
+// passed as is to the elements option of cytoscape().
+type CytoscapeElements struct {
+	Nodes []CytoscapeNode `json:"nodes"`
+	Edges []CytoscapeEdge `json:"edges"`
+}
+
+type CytoscapeNode struct {
+	Data CytoscapeNodeData `json:"data"`
+}
+
+// CytoscapeNodeData carries the fields of the graph format next to the id
+// and label Cytoscape.js expects.
+type CytoscapeNodeData struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Node
+}
+
+type CytoscapeEdge struct {
+	Data CytoscapeEdgeData `json:"data"`
+}
+
+type CytoscapeEdgeData struct {
+	ID string `json:"id"`
+	Edge
+}
+
+// graphNodeLabel returns the name of a node without the org and type
+// prefix, e.g. the team name.
+func graphNodeLabel(name string) string {
+	parts := strings.SplitN(name, ".", 3)
+	return parts[len(parts)-1]
+}
+
+func encodeCytoscape(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	elements := CytoscapeElements{Nodes: []CytoscapeNode{}, Edges: []CytoscapeEdge{}}
+
+	for _, n := range graph.Nodes() {
+		elements.Nodes = append(elements.Nodes, CytoscapeNode{Data: CytoscapeNodeData{ID: n.Name, Label: graphNodeLabel(n.Name), Node: n}})
+	}
+
+	for i, e := range graph.Edges() {
+		elements.Edges = append(elements.Edges, CytoscapeEdge{Data: CytoscapeEdgeData{ID: fmt.Sprintf("e%d", i), Edge: e}})
+	}
+
+	return marshalIndented(elements)
+}
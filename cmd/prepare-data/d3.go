@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// D3Graph is the nodes and links structure of D3 force simulations.
+type D3Graph struct {
+	Nodes []D3Node `json:"nodes"`
+	Links []D3Link `json:"links"`
+}
+
+// D3Node carries the fields of the graph format next to an id and the
+// group D3 examples color nodes by.
+type D3Node struct {
+	ID    string `json:"id"`
+	Group string `json:"group,omitempty"`
+	Node
+}
+
+// D3Link refers to nodes by their index, which is what d3.forceLink uses
+// unless given an id accessor. The weight is the value.
+type D3Link struct {
+	Source   int    `json:"source"`
+	Target   int    `json:"target"`
+	Value    int    `json:"value"`
+	Kind     string `json:"kind"`
+	Directed bool   `json:"directed"`
+}
+
+func encodeD3(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	d3 := D3Graph{Nodes: []D3Node{}, Links: []D3Link{}}
+	index := map[string]int{}
+
+	for i, n := range graph.Nodes() {
+		index[n.Name] = i
+		d3.Nodes = append(d3.Nodes, D3Node{ID: n.Name, Group: n.Type, Node: n})
+	}
+
+	for _, e := range graph.Edges() {
+		d3.Links = append(d3.Links, D3Link{Source: index[e.Source], Target: index[e.Target], Value: e.Weight, Kind: e.Kind, Directed: e.Directed})
+	}
+
+	return marshalIndented(d3)
+}
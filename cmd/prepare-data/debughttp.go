@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// debugTransport logs every request with --debug-http. Only the method, the
+// sanitized URL, the status, the rate limit headers and the timing are
+// logged, never request headers, which carry the credentials.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+// sensitiveParameters are query parameters whose values are redacted, like
+// the signatures of presigned URLs.
+var sensitiveParameters = []string{"token", "signature", "credential", "secret", "key", "code"}
+
+// sanitizeURL keeps the path of GitHub API requests, with sensitive query
+// parameters redacted. Other hosts, like Slack webhooks, can have secrets
+// anywhere in their URLs, so only their host is kept.
+func sanitizeURL(u *url.URL) string {
+	sanitized := *u
+	sanitized.User = nil
+	if !isGitHubAPIHost(u.Host) {
+		sanitized.Path, sanitized.RawPath, sanitized.RawQuery, sanitized.Fragment = "/redacted", "", "", ""
+		return sanitized.String()
+	}
+	query := sanitized.Query()
+	redacted := false
+	for name := range query {
+		for _, sensitive := range sensitiveParameters {
+			if strings.Contains(strings.ToLower(name), sensitive) {
+				query.Set(name, "redacted")
+				redacted = true
+			}
+		}
+	}
+	// Encoding sorts the parameters, so the query is only rewritten when
+	// needed.
+	if redacted {
+		sanitized.RawQuery = query.Encode()
+	}
+	return sanitized.String()
+}
+
+func isGitHubAPIHost(host string) bool {
+	api, err := url.Parse(github.APIURL)
+	return err == nil && strings.EqualFold(api.Host, host)
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	target := sanitizeURL(req.URL)
+	if err != nil {
+		log.Printf("http: %s %s: %v after %s\n", req.Method, target, err, elapsed)
+		return nil, err
+	}
+
+	rateLimit := ""
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		rateLimit = fmt.Sprintf(", %s rate limit %s of %s left", resp.Header.Get("X-RateLimit-Resource"), remaining, resp.Header.Get("X-RateLimit-Limit"))
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			rateLimit += " until " + time.Unix(reset, 0).Format(time.Kitchen)
+		}
+	}
+	log.Printf("http: %s %s: %s in %s%s\n", req.Method, target, resp.Status, elapsed, rateLimit)
+
+	return resp, nil
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	teamAdded   = "add"
+	teamRemoved = "remove"
+	teamChanged = "change"
+	teamRenamed = "rename"
+)
+
+// TeamChange is one entry of the structured diff between two team lists.
+type TeamChange struct {
+	Op             string   `json:"op"`
+	Team           string   `json:"team"`
+	RenamedFrom    string   `json:"renamed_from,omitempty"`
+	Members        int      `json:"members,omitempty"`
+	AddedMembers   []string `json:"added_members,omitempty"`
+	RemovedMembers []string `json:"removed_members,omitempty"`
+	ParentBefore   *string  `json:"parent_before,omitempty"`
+	ParentAfter    *string  `json:"parent_after,omitempty"`
+}
+
+// teamChanges compares teams by name, following renames. It lists added,
+// removed and renamed teams and teams with changed members or parent,
+// sorted by name.
+func teamChanges(before, after []Team, renames []TeamRename) []TeamChange {
+	renamedFrom := map[string]string{}
+	for _, rename := range renames {
+		renamedFrom[rename.To] = rename.From
+	}
+	beforeByName, replaced := renamedByName(before, renames)
+
+	afterByName := map[string]Team{}
+	for _, team := range after {
+		afterByName[team.Name] = team
+	}
+
+	names := []string{}
+	for name := range beforeByName {
+		names = append(names, name)
+	}
+	for name := range afterByName {
+		if _, ok := beforeByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []TeamChange{}
+
+	for _, name := range names {
+		oldTeam, existed := beforeByName[name]
+		newTeam, exists := afterByName[name]
+
+		if team, ok := replaced[name]; ok {
+			changes = append(changes, TeamChange{Op: teamRemoved, Team: name, Members: len(team.Members)})
+		}
+
+		switch {
+		case !existed:
+			changes = append(changes, TeamChange{Op: teamAdded, Team: name, Members: len(newTeam.Members)})
+		case !exists:
+			changes = append(changes, TeamChange{Op: teamRemoved, Team: name, Members: len(oldTeam.Members)})
+		default:
+			change := TeamChange{Op: teamChanged, Team: name}
+			if from, ok := renamedFrom[name]; ok {
+				change.Op, change.RenamedFrom = teamRenamed, from
+			}
+			for _, member := range newTeam.Members {
+				if !contains(oldTeam.Members, member) {
+					change.AddedMembers = append(change.AddedMembers, member)
+				}
+			}
+			for _, member := range oldTeam.Members {
+				if !contains(newTeam.Members, member) {
+					change.RemovedMembers = append(change.RemovedMembers, member)
+				}
+			}
+			if parentSlug(oldTeam) != parentSlug(newTeam) {
+				before, after := parentSlug(oldTeam), parentSlug(newTeam)
+				change.ParentBefore, change.ParentAfter = &before, &after
+			}
+			if change.Op == teamRenamed || len(change.AddedMembers) > 0 || len(change.RemovedMembers) > 0 || change.ParentAfter != nil {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes
+}
+
+// renamedByName returns the teams of before keyed by their name after the
+// renames. A team that keeps its name while another one is renamed to it is
+// replaced, those are returned separately.
+func renamedByName(before []Team, renames []TeamRename) (map[string]Team, map[string]Team) {
+	from, to := map[string]bool{}, map[string]bool{}
+	for _, rename := range renames {
+		from[rename.From], to[rename.To] = true, true
+	}
+
+	byName, replaced := map[string]Team{}, map[string]Team{}
+	for i, team := range renamedTeams(before, renames) {
+		if to[before[i].Name] && !from[before[i].Name] {
+			replaced[team.Name] = team
+			continue
+		}
+		byName[team.Name] = team
+	}
+	return byName, replaced
+}
+
+func (c TeamChange) String() string {
+	switch c.Op {
+	case teamAdded:
+		return fmt.Sprintf("+ %s (%d members)", c.Team, c.Members)
+	case teamRemoved:
+		return fmt.Sprintf("- %s (%d members)", c.Team, c.Members)
+	}
+
+	line := ""
+	if c.Op == teamRenamed {
+		line += fmt.Sprintf(" renamed from %s", c.RenamedFrom)
+	}
+	for _, member := range c.AddedMembers {
+		line += " +" + member
+	}
+	for _, member := range c.RemovedMembers {
+		line += " -" + member
+	}
+	if c.ParentAfter != nil {
+		line += fmt.Sprintf(" parent %q -> %q", *c.ParentBefore, *c.ParentAfter)
+	}
+	return "~ " + c.Team + ":" + line
+}
+
+// diffTeams describes how the teams changed, one line per added, removed or
+// renamed team and per team with changed members or parent.
+func diffTeams(before, after []Team, renames []TeamRename) []string {
+	lines := []string{}
+	for _, change := range teamChanges(before, after, renames) {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
+
+func parentSlug(team Team) string {
+	if team.Parent == nil {
+		return ""
+	}
+	return team.Parent.Slug
+}
+
+// PatchOperation is an RFC 6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	From  string      `json:"from,omitempty"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON leaves out the value of remove and move operations, others
+// need one even if it is null.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case "remove":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	case "move":
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			From string `json:"from"`
+			Path string `json:"path"`
+		}{o.Op, o.From, o.Path})
+	}
+	type operation PatchOperation
+	return json.Marshal(operation(o))
+}
+
+func jsonPointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// teamsPatch returns a JSON Patch turning before into after, where both are
+// encoded as an object of teams keyed by team name. Renamed teams are moved.
+func teamsPatch(before, after []Team, renames []TeamRename) []PatchOperation {
+	beforeByName, _ := renamedByName(before, renames)
+	afterByName := map[string]Team{}
+	for _, team := range after {
+		afterByName[team.Name] = team
+	}
+
+	// Removals come first and renames next, so a team added under the old
+	// name of a renamed team, or a team renamed to the name of a removed
+	// one, isn't moved or removed in its place.
+	removals, moves, patch := []PatchOperation{}, []PatchOperation{}, []PatchOperation{}
+
+	for _, change := range teamChanges(before, after, renames) {
+		path := "/" + jsonPointerToken(change.Team)
+
+		ops := &patch
+		if change.Op == teamRenamed {
+			ops = &moves
+			newTeam := afterByName[change.Team]
+			moves = append(moves,
+				PatchOperation{Op: "move", From: "/" + jsonPointerToken(change.RenamedFrom), Path: path},
+				PatchOperation{Op: "replace", Path: path + "/name", Value: newTeam.Name},
+			)
+			if beforeByName[change.Team].Slug != newTeam.Slug {
+				moves = append(moves, PatchOperation{Op: "replace", Path: path + "/slug", Value: newTeam.Slug})
+			}
+		}
+
+		switch change.Op {
+		case teamAdded:
+			patch = append(patch, PatchOperation{Op: "add", Path: path, Value: afterByName[change.Team]})
+		case teamRemoved:
+			removals = append(removals, PatchOperation{Op: "remove", Path: path})
+		default:
+			members := beforeByName[change.Team].Members
+			// Remove from the back so the indices of earlier members stay valid.
+			for i := len(members) - 1; i >= 0; i-- {
+				if contains(change.RemovedMembers, members[i]) {
+					*ops = append(*ops, PatchOperation{Op: "remove", Path: fmt.Sprintf("%s/members/%d", path, i)})
+				}
+			}
+			for _, member := range change.AddedMembers {
+				*ops = append(*ops, PatchOperation{Op: "add", Path: path + "/members/-", Value: member})
+			}
+			if change.ParentAfter != nil {
+				parent := afterByName[change.Team].Parent
+				var value interface{}
+				if parent != nil {
+					value = parent
+				}
+				*ops = append(*ops, PatchOperation{Op: "replace", Path: path + "/parent", Value: value})
+			}
+		}
+	}
+
+	return append(append(removals, moves...), patch...)
+}
+
+const (
+	diffFormatText      = "text"
+	diffFormatChanges   = "changes"
+	diffFormatJSONPatch = "json-patch"
+)
+
+func runDiff(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	snapshotDir := flags.String("snapshot-dir", "", "compare the two newest snapshots in this directory, s3://bucket/prefix or sqlite:file.db instead of two snapshot files")
+	format := flags.String("format", diffFormatText, "output format: text, changes (a json change list) or json-patch (RFC 6902, against an object of teams keyed by name)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [flags] <old snapshot> <new snapshot>\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var before, after Snapshot
+	var err error
+
+	switch {
+	case *snapshotDir != "" && flags.NArg() == 0:
+		store, err := openSnapshotStore(*snapshotDir, defaultFilePermissions)
+		if err != nil {
+			return err
+		}
+		defer store.close()
+		snapshots, err := loadSnapshots(ctx, store, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+		if len(snapshots) < 2 {
+			return fmt.Errorf("Need at least two snapshots in '%s', found %d", *snapshotDir, len(snapshots))
+		}
+		before, after = snapshots[len(snapshots)-2], snapshots[len(snapshots)-1]
+	case *snapshotDir == "" && flags.NArg() == 2:
+		before, err = readSnapshot(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		after, err = readSnapshot(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("Expected two snapshot files or --snapshot-dir")
+	}
+
+	renames := snapshotRenames(before, after)
+
+	switch *format {
+	case diffFormatText:
+		for _, line := range diffTeams(before.Teams, after.Teams, renames) {
+			fmt.Println(line)
+		}
+		return nil
+	case diffFormatChanges:
+		data, err := marshalIndented(teamChanges(before.Teams, after.Teams, renames))
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	case diffFormatJSONPatch:
+		data, err := marshalIndented(teamsPatch(before.Teams, after.Teams, renames))
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	return fmt.Errorf("Unknown diff format '%s', expected one of: %s, %s, %s", *format, diffFormatText, diffFormatChanges, diffFormatJSONPatch)
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// teamsDocument decodes teams the way the json-patch diff format addresses
+// them, as an object of teams keyed by name.
+func teamsDocument(t *testing.T, teams []Team) interface{} {
+	t.Helper()
+	byName := map[string]Team{}
+	for _, team := range teams {
+		byName[team.Name] = team
+	}
+	return roundTrip(t, byName)
+}
+
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded interface{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+// applyPatch applies the add, remove, replace and move operations of an RFC
+// 6902 patch, as much of the RFC as teamsPatch uses.
+func applyPatch(t *testing.T, doc interface{}, patch []PatchOperation) (interface{}, error) {
+	for _, op := range patch {
+		var err error
+		switch op.Op {
+		case "add", "replace":
+			doc, err = patchPointer(doc, op.Path, op.Op, roundTrip(t, op.Value))
+		case "remove":
+			doc, err = patchPointer(doc, op.Path, op.Op, nil)
+		case "move":
+			var value interface{}
+			value, err = lookupPointer(doc, op.From)
+			if err == nil {
+				doc, err = patchPointer(doc, op.From, "remove", nil)
+			}
+			if err == nil {
+				doc, err = patchPointer(doc, op.Path, "add", value)
+			}
+		default:
+			err = fmt.Errorf("unsupported op")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func pointerTokens(pointer string) []string {
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens
+}
+
+func lookupPointer(doc interface{}, pointer string) (interface{}, error) {
+	for _, token := range pointerTokens(pointer) {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %s", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("no index %s", token)
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("no member %s", token)
+		}
+	}
+	return doc, nil
+}
+
+// patchPointer adds, removes or replaces the value at pointer and returns the
+// changed document.
+func patchPointer(doc interface{}, pointer, op string, value interface{}) (interface{}, error) {
+	tokens := pointerTokens(pointer)
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent := doc
+	if parentPointer != "" {
+		var err error
+		parent, err = lookupPointer(doc, parentPointer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	last := tokens[len(tokens)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok && op != "add" {
+			return nil, fmt.Errorf("no member %s", last)
+		}
+		if op == "remove" {
+			delete(node, last)
+		} else {
+			node[last] = value
+		}
+		return doc, nil
+	case []interface{}:
+		if op == "add" && last == "-" {
+			return patchPointer(doc, parentPointer, "replace", append(node, value))
+		}
+		i, err := strconv.Atoi(last)
+		if err != nil || i < 0 || i >= len(node) {
+			return nil, fmt.Errorf("no index %s", last)
+		}
+		items := append([]interface{}{}, node[:i]...)
+		switch op {
+		case "add":
+			items = append(append(items, value), node[i:]...)
+		case "remove":
+			items = append(items, node[i+1:]...)
+		default:
+			items = append(append(items, value), node[i+1:]...)
+		}
+		return patchPointer(doc, parentPointer, "replace", items)
+	}
+	return nil, fmt.Errorf("no member %s", last)
+}
+
+func TestTeamsPatch(t *testing.T) {
+	cases := map[string]struct {
+		before []Team
+		after  []Team
+		// renames are detected when nil.
+		renames []TeamRename
+	}{
+		"rename next to a removed team": {
+			before: []Team{
+				{Name: "team-a", Slug: "team-a", Members: []string{"alice", "bob", "carol"}},
+				{Name: "team-c", Slug: "team-c", Members: []string{"dave"}},
+			},
+			after: []Team{
+				{Name: "team-b", Slug: "team-b", Members: []string{"alice", "bob", "carol"}},
+			},
+		},
+		"rename onto a removed name": {
+			before: []Team{
+				{Name: "team-a", Slug: "team-a", Members: []string{"alice", "bob", "carol"}},
+				{Name: "team-b", Slug: "team-b", Members: []string{"dave"}},
+			},
+			after: []Team{
+				{Name: "team-b", Slug: "team-b", Members: []string{"alice", "bob", "carol", "erin"}},
+			},
+			renames: []TeamRename{{From: "team-a", To: "team-b", Similarity: 0.75}},
+		},
+		"add under the old name of a renamed team": {
+			before: []Team{
+				{Name: "team-b", Slug: "team-b", Members: []string{"alice", "bob", "carol"}},
+			},
+			after: []Team{
+				{Name: "team-b", Slug: "team-b2", Members: []string{"frank"}},
+				{Name: "team-c", Slug: "team-c", Members: []string{"alice", "bob", "carol", "dave"}},
+			},
+			renames: []TeamRename{{From: "team-b", To: "team-c", Similarity: 0.75}},
+		},
+		"member removals by index": {
+			before: []Team{
+				{Name: "team-a", Slug: "team-a", Members: []string{"alice", "bob", "carol", "dave", "erin"}},
+			},
+			after: []Team{
+				{Name: "team-a", Slug: "team-a", Members: []string{"alice", "dave", "frank"}},
+			},
+		},
+		"renamed team with member changes and a new parent": {
+			before: []Team{
+				{Name: "team-parent", Slug: "team-parent", Members: []string{"zoe"}},
+				{Name: "team-a", Slug: "team-a", Members: []string{"alice", "bob", "carol", "dave"}},
+			},
+			after: []Team{
+				{Name: "team-parent", Slug: "team-parent", Members: []string{"zoe"}},
+				{Name: "team-b", Slug: "team-b", Parent: &TeamRef{Name: "team-parent", Slug: "team-parent"}, Members: []string{"alice", "carol", "dave", "erin"}},
+			},
+		},
+		"names needing escapes": {
+			before: []Team{
+				{Name: "team/a~1", Slug: "team-a-1", Members: []string{"alice", "bob"}},
+			},
+			after: []Team{
+				{Name: "team/a~1", Slug: "team-a-1", Members: []string{"bob", "carol"}},
+			},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			renames := c.renames
+			if renames == nil {
+				renames = detectRenames(c.before, c.after)
+			}
+
+			patched, err := applyPatch(t, teamsDocument(t, c.before), teamsPatch(c.before, c.after, renames))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := teamsDocument(t, c.after)
+			if !reflect.DeepEqual(patched, expected) {
+				got, _ := json.Marshal(patched)
+				want, _ := json.Marshal(expected)
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestTeamChangesRenameOntoRemovedName(t *testing.T) {
+	before := []Team{
+		{Name: "team-a", Slug: "team-a", Members: []string{"alice", "bob"}},
+		{Name: "team-b", Slug: "team-b", Members: []string{"dave"}},
+	}
+	after := []Team{
+		{Name: "team-b", Slug: "team-b", Members: []string{"alice", "bob"}},
+	}
+
+	lines := diffTeams(before, after, []TeamRename{{From: "team-a", To: "team-b", Similarity: 1}})
+	expected := []string{"- team-b (1 members)", "~ team-b: renamed from team-a"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// discussionsPerPage is the page size discussions are fetched in.
+const discussionsPerPage = 100
+
+// fetchDiscussionPosts counts the discussions of a team created since. They
+// are listed newest first, so pages are only fetched until one reaches
+// past since.
+func fetchDiscussionPosts(ctx context.Context, org, slug string, since time.Time) (int, error) {
+	progress.Printf("fetching team discussions for '%s'\n", slug)
+
+	posts := 0
+	for page := 1; ; page++ {
+		discussionBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams/%s/discussions?direction=desc&per_page=%d&page=%d", github.APIURL, org, slug, discussionsPerPage, page))
+		if err != nil {
+			return 0, fmt.Errorf("Error fetching discussions for slug %s: %w", slug, err)
+		}
+
+		var discussions []struct {
+			CreatedAt time.Time `json:"created_at"`
+		}
+
+		err = json.Unmarshal(discussionBytes, &discussions)
+		if err != nil {
+			return 0, fmt.Errorf("Error parsing discussions for slug %s: %w", slug, err)
+		}
+
+		for _, discussion := range discussions {
+			if discussion.CreatedAt.Before(since) {
+				return posts, nil
+			}
+			posts++
+		}
+		if len(discussions) < discussionsPerPage {
+			return posts, nil
+		}
+	}
+}
+
+// attachDiscussionActivity counts the discussion posts each team created
+// within the window. Teams for which discussions can't be read (e.g.
+// because they are disabled) are left without the attribute.
+func attachDiscussionActivity(ctx context.Context, org string, teams []Team, window time.Duration) {
+	since := time.Now().Add(-window)
+
+	for i := range teams {
+		posts, err := fetchDiscussionPosts(ctx, org, teams[i].Slug, since)
+		if err != nil {
+			log.Printf("skipping discussion activity for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+		teams[i].DiscussionPosts = &posts
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+func TestFetchDiscussionPostsPagesUntilTheWindow(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	// One discussion per hour, newest first.
+	created := []time.Time{}
+	for i := 0; i < 250; i++ {
+		created = append(created, now.Add(-time.Duration(i)*time.Hour))
+	}
+
+	pages := []int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pages = append(pages, page)
+		discussions := []map[string]time.Time{}
+		for i := (page - 1) * discussionsPerPage; i < page*discussionsPerPage && i < len(created); i++ {
+			discussions = append(discussions, map[string]time.Time{"created_at": created[i]})
+		}
+		json.NewEncoder(w).Encode(discussions)
+	}))
+	defer srv.Close()
+
+	previous := github.APIURL
+	github.APIURL = srv.URL
+	defer func() { github.APIURL = previous }()
+	t.Setenv("GITHUB_TOKEN", "test")
+
+	cases := []struct {
+		window time.Duration
+		posts  int
+		pages  int
+	}{
+		{window: 10*time.Hour - time.Minute, posts: 10, pages: 1},
+		{window: 150*time.Hour - time.Minute, posts: 150, pages: 2},
+		{window: 1000 * time.Hour, posts: 250, pages: 3},
+	}
+	for _, c := range cases {
+		pages = nil
+		posts, err := fetchDiscussionPosts(context.Background(), "org", "team-a", now.Add(-c.window))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if posts != c.posts {
+			t.Errorf("expected %d posts within %s, got %d", c.posts, c.window, posts)
+		}
+		if len(pages) != c.pages {
+			t.Errorf("expected %d pages for %s, got %v", c.pages, c.window, pages)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	encryptAge = "age"
+	encryptGPG = "gpg"
+)
+
+// encryption encrypts output files to recipients with the age or gpg
+// command. Encrypting the same data twice gives different files, so
+// encrypted outputs are written on every run.
+type encryption struct {
+	tool       string
+	recipients []string
+}
+
+func encryptFlag(tool *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case encryptAge, encryptGPG:
+			*tool = value
+			return nil
+		}
+		return fmt.Errorf("expected %s or %s, got '%s'", encryptAge, encryptGPG, value)
+	}
+}
+
+func recipientFlag(recipients *[]string) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			return fmt.Errorf("expected a recipient")
+		}
+		*recipients = append(*recipients, value)
+		return nil
+	}
+}
+
+func (e encryption) enabled() bool {
+	return e.tool != ""
+}
+
+func (e encryption) validate() error {
+	if !e.enabled() {
+		if len(e.recipients) > 0 {
+			return fmt.Errorf("--recipient requires --encrypt")
+		}
+		return nil
+	}
+	if len(e.recipients) == 0 {
+		return fmt.Errorf("--encrypt requires at least one --recipient")
+	}
+	_, err := exec.LookPath(e.tool)
+	if err != nil {
+		return fmt.Errorf("--encrypt %s requires the %s command: %w", e.tool, e.tool, err)
+	}
+	return nil
+}
+
+// extension is appended to the paths of encrypted outputs.
+func (e encryption) extension() string {
+	return "." + e.tool
+}
+
+func (e encryption) encrypt(data []byte) ([]byte, error) {
+	var args []string
+	if e.tool == encryptGPG {
+		// Recipients are given explicitly, so their keys don't need to be
+		// trusted in the keyring.
+		args = []string{"--batch", "--yes", "--trust-model", "always", "--output", "-", "--encrypt"}
+	}
+	for _, recipient := range e.recipients {
+		args = append(args, "--recipient", recipient)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(e.tool, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+
+	encrypted, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error encrypting with %s: %w: %s", e.tool, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return encrypted, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// edgeExternal is the default kind of edges declared in the config.
+const edgeExternal = "external"
+
+// defaultExtraNamespace is the namespace of extra nodes that don't set one.
+const defaultExtraNamespace = "external"
+
+// extraGraph declares nodes and edges for entities that don't exist in
+// GitHub, like partner teams or vendors. Extra nodes are named
+// namespace.name, so they can't collide with the nodes of the org, which
+// are prefixed with the org.
+type extraGraph struct {
+	Nodes []ExtraNode `yaml:"nodes"`
+	Edges []ExtraEdge `yaml:"edges"`
+}
+
+type ExtraNode struct {
+	Name      string   `yaml:"name"`
+	Namespace string   `yaml:"namespace"`
+	Type      string   `yaml:"type"`
+	Labels    []string `yaml:"labels"`
+}
+
+func (n ExtraNode) graphName() string {
+	return n.Namespace + "." + strings.ReplaceAll(n.Name, " ", "")
+}
+
+// ExtraEdge links two nodes given as the name of an extra node
+// (namespace.name), a team name or the name of any other graph node.
+type ExtraEdge struct {
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	Kind     string `yaml:"kind"`
+	Weight   int    `yaml:"weight"`
+	Directed bool   `yaml:"directed"`
+}
+
+// validate fills in the defaults and refuses incomplete declarations and
+// namespaces that would mix extra nodes with those of org.
+func (x *extraGraph) validate(org string) error {
+	names := map[string]bool{}
+	namespaces := map[string]bool{}
+	for i := range x.Nodes {
+		n := &x.Nodes[i]
+		if n.Name == "" {
+			return fmt.Errorf("Extra node %d has no name", i+1)
+		}
+		if n.Namespace == "" {
+			n.Namespace = defaultExtraNamespace
+		}
+		if strings.Contains(n.Namespace, ".") {
+			return fmt.Errorf("Namespace '%s' of extra node '%s' can't contain dots", n.Namespace, n.Name)
+		}
+		if org != "" && strings.EqualFold(n.Namespace, org) {
+			return fmt.Errorf("Extra node '%s' can't use the namespace of org '%s'", n.Name, org)
+		}
+		if n.Type == "" {
+			n.Type = n.Namespace
+		}
+		if names[n.graphName()] {
+			return fmt.Errorf("Extra node '%s' is declared twice", n.graphName())
+		}
+		names[n.graphName()] = true
+		namespaces[n.Namespace] = true
+	}
+
+	for i := range x.Edges {
+		e := &x.Edges[i]
+		if e.Source == "" || e.Target == "" {
+			return fmt.Errorf("Extra edge %d needs a source and a target", i+1)
+		}
+		for _, end := range []string{e.Source, e.Target} {
+			namespace := strings.SplitN(end, ".", 2)[0]
+			if namespaces[namespace] && !names[end] {
+				return fmt.Errorf("Extra edge %d refers to undeclared node '%s'", i+1, end)
+			}
+		}
+		if e.Kind == "" {
+			e.Kind = edgeExternal
+		}
+		if e.Weight == 0 {
+			e.Weight = 1
+		}
+		if e.Weight < 0 {
+			return fmt.Errorf("Extra edge from '%s' to '%s' has a negative weight", e.Source, e.Target)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the graph node name of an edge end.
+func (x *extraGraph) resolve(g *Graph, name string, opts *options) (string, bool) {
+	if _, ok := g.Node(name); ok {
+		return name, true
+	}
+	teamName, _, err := opts.taxonomy.graphTeamName(opts.org, name)
+	if err != nil {
+		return "", false
+	}
+	_, ok := g.Node(teamName)
+	return teamName, ok
+}
+
+// addTo merges the extra nodes and edges into g. Edges to teams left out
+// by the filters are skipped.
+func (x *extraGraph) addTo(g *Graph, opts *options) {
+	for _, n := range x.Nodes {
+		g.AddNode(Node{Name: n.graphName(), Type: n.Type, Labels: n.Labels})
+	}
+
+	for _, e := range x.Edges {
+		source, ok := x.resolve(g, e.Source, opts)
+		if !ok {
+			continue
+		}
+		target, ok := x.resolve(g, e.Target, opts)
+		if !ok {
+			continue
+		}
+		g.AddEdge(Edge{Source: source, Target: target, Kind: e.Kind, Weight: e.Weight, Directed: e.Directed})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// filePermissions controls the mode and ownership of written files. A uid
+// or gid of -1 leaves the respective owner unchanged.
+type filePermissions struct {
+	mode         os.FileMode
+	respectUmask bool
+	uid          int
+	gid          int
+}
+
+var defaultFilePermissions = filePermissions{mode: 0644, uid: -1, gid: -1}
+
+func (p filePermissions) writeFile(path string, data []byte) error {
+	err := os.WriteFile(path, data, p.mode)
+	if err != nil {
+		return err
+	}
+
+	// os.WriteFile only applies the mode, filtered by the umask, to newly
+	// created files.
+	if !p.respectUmask {
+		err = os.Chmod(path, p.mode)
+		if err != nil {
+			return fmt.Errorf("Error changing mode of '%s': %w", path, err)
+		}
+	}
+
+	if p.uid != -1 || p.gid != -1 {
+		err = os.Chown(path, p.uid, p.gid)
+		if err != nil {
+			return fmt.Errorf("Error changing ownership of '%s': %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func fileModeFlag(mode *os.FileMode) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || parsed > 0777 {
+			return fmt.Errorf("expected an octal file mode like 0644, got '%s'", value)
+		}
+		*mode = os.FileMode(parsed)
+		return nil
+	}
+}
+
+func fileOwnerFlag(uid *int) func(string) error {
+	return func(value string) error {
+		if id, err := strconv.Atoi(value); err == nil {
+			*uid = id
+			return nil
+		}
+		u, err := user.Lookup(value)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return fmt.Errorf("user '%s' has no numeric id", value)
+		}
+		*uid = id
+		return nil
+	}
+}
+
+func fileGroupFlag(gid *int) func(string) error {
+	return func(value string) error {
+		if id, err := strconv.Atoi(value); err == nil {
+			*gid = id
+			return nil
+		}
+		g, err := user.LookupGroup(value)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return fmt.Errorf("group '%s' has no numeric id", value)
+		}
+		*gid = id
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type format struct {
+	name   string
+	output string
+	encode func(ctx context.Context, teams []Team, opts *options) ([]byte, error)
+}
+
+var formats = []format{
+	{name: "graph", output: "assets/org-vis/teams-graph.json", encode: encodeGraph},
+	{name: "edges", output: "assets/org-vis/teams-edges.json", encode: encodeEdges},
+	{name: "sunburst", output: "assets/org-vis/teams-sunburst.json", encode: encodeSunburst},
+	{name: "chord", output: "assets/org-vis/teams-chord.json", encode: encodeChord},
+	{name: "heatmap", output: "assets/org-vis/teams-heatmap.json", encode: encodeHeatmap},
+	{name: "heatmap-csv", output: "assets/org-vis/teams-heatmap.csv", encode: encodeHeatmapCSV},
+	{name: "orgchart", output: "assets/org-vis/teams-orgchart.json", encode: encodeOrgChart},
+	{name: "mermaid-tree", output: "assets/org-vis/teams-tree.mmd", encode: encodeMermaidTree},
+	{name: "mermaid", output: "assets/org-vis/teams-graph.mmd", encode: encodeMermaid},
+	{name: "plantuml", output: "assets/org-vis/teams.puml", encode: encodePlantUML},
+	{name: "svg", output: "assets/org-vis/teams.svg", encode: encodeSVG},
+	{name: "png", output: "assets/org-vis/teams.png", encode: encodePNG},
+	{name: "template", output: "assets/org-vis/teams-template.txt", encode: encodeTemplate},
+	{name: "types", output: "assets/org-vis/teams-types.json", encode: encodeTypes},
+	{name: "badge", output: "assets/org-vis/teams-badge.json", encode: encodeBadge},
+	{name: "metrics", output: "assets/org-vis/teams-metrics.json", encode: encodeMetrics},
+	{name: "sankey", output: "assets/org-vis/teams-sankey.json", encode: encodeSankey},
+	{name: "history", output: "assets/org-vis/teams-history.json", encode: encodeHistory},
+	{name: "graphml", output: "assets/org-vis/teams.graphml", encode: encodeGraphML},
+	{name: "cytoscape", output: "assets/org-vis/teams-cytoscape.json", encode: encodeCytoscape},
+	{name: "d3", output: "assets/org-vis/teams-d3.json", encode: encodeD3},
+	{name: "nodes-csv", output: "assets/org-vis/teams-nodes.csv", encode: encodeNodesCSV},
+	{name: "edges-csv", output: "assets/org-vis/teams-edges.csv", encode: encodeEdgesCSV},
+	{name: "ldif", output: "assets/org-vis/teams.ldif", encode: encodeLDIF},
+	{name: cypherFormat, output: "assets/org-vis/teams.cypher", encode: encodeCypher},
+	{name: turtleFormat, output: "assets/org-vis/teams.ttl", encode: encodeTurtle},
+	{name: membershipMatrixFormat, output: "assets/org-vis/teams-membership.csv", encode: encodeMembershipCSV},
+}
+
+func formatNames() []string {
+	names := []string{}
+	for _, f := range formats {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func parseFormats(value string) ([]format, error) {
+	selected := []format{}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, f := range formats {
+			if f.name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown format '%s', expected one of: %s", name, strings.Join(formatNames(), ", "))
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No output format selected")
+	}
+
+	return selected, nil
+}
+
+func marshalIndented(v interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling into json: %w", err)
+	}
+
+	var indentedBytes bytes.Buffer
+
+	err = json.Indent(&indentedBytes, jsonBytes, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Error indenting json: %w", err)
+	}
+
+	return indentedBytes.Bytes(), nil
+}
+
+func encodeEdges(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	return marshalIndented(graph.Edges())
+}
+
+func encodeGraph(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	return marshalIndented(graph)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// collectTeams fetches the relevant teams and everything attached to them
+// according to the options.
+func collectTeams(ctx context.Context, opts *options) ([]Team, error) {
+	var teams []Team
+	var err error
+	switch {
+	case opts.source != "":
+		teams, err = loadTeamSource(opts.source, opts.filter)
+	case opts.api == apiGraphQL:
+		teams, err = fetchTeamsGraphQL(ctx, opts.org, opts.filter)
+	default:
+		teams, err = fetchTeams(ctx, opts.org, opts.filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error collecting the teams of %s: %w", opts.org, err)
+	}
+
+	if len(opts.filter.names) > 0 {
+		teams = mergeNormalizedTeams(teams)
+	}
+
+	// Before the what-if overlay, so merged teams keep the rotations.
+	if opts.onCall != nil {
+		opts.onCall.attach(teams)
+	}
+
+	// Local team sources already include the maintainers.
+	if opts.needsMaintainers() && opts.source == "" {
+		err = attachMaintainers(ctx, opts.org, teams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.whatIf != nil {
+		before := teams
+		teams, err = opts.whatIf.apply(teams)
+		if err != nil {
+			return nil, err
+		}
+		printComparison(progress.Writer(), []OrgStructure{orgStructure(opts.org, before), orgStructure(opts.org+" (what-if)", teams)})
+	}
+
+	if opts.rollupMembers {
+		rollupMembers(teams)
+	}
+
+	if opts.projects {
+		err = attachProjects(ctx, opts.org, teams)
+		if err != nil {
+			return nil, fmt.Errorf("Error attaching projects: %w", err)
+		}
+	}
+
+	if opts.repoTopology {
+		opts.repoRelations, err = fetchRepoTopology(ctx, opts.org)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = attachLabels(teams, opts.labelConvention, opts.labelMappings)
+	if err != nil {
+		return nil, fmt.Errorf("Error mapping labels: %w", err)
+	}
+
+	if opts.discussions {
+		attachDiscussionActivity(ctx, opts.org, teams, opts.discussionsWindow)
+	}
+
+	if opts.teamSync {
+		attachTeamSync(ctx, opts.org, teams)
+	}
+
+	if opts.ownershipHealth {
+		attachOwnershipHealth(ctx, opts.org, teams)
+	}
+
+	if opts.reviewLatency {
+		attachReviewLatency(ctx, opts.org, teams, opts.reviewLatencyWindow)
+	}
+
+	// Last, as the what-if overlay refers to the actual logins.
+	if opts.pseudonyms != nil {
+		opts.pseudonyms.apply(teams)
+	}
+
+	return teams, nil
+}
+
+type output struct {
+	format format
+	path   string
+	data   []byte
+}
+
+func storeSnapshot(ctx context.Context, teams []Team, opts *options) error {
+	if opts.snapshotDir == "" {
+		return nil
+	}
+
+	snapshot := Snapshot{TakenAt: time.Now().UTC(), Teams: teams}
+	previous, err := latestSnapshot(ctx, opts.snapshots)
+	if err != nil {
+		return fmt.Errorf("Error reading previous snapshot: %w", err)
+	}
+	if previous != nil {
+		snapshot.Renames = detectRenames(previous.Teams, teams)
+	}
+
+	location, err := opts.snapshots.save(ctx, snapshot)
+	if err != nil {
+		return fmt.Errorf("Error saving snapshot: %w", err)
+	}
+	progress.Printf("saved snapshot to %s\n", location)
+
+	removed, err := pruneSnapshots(ctx, opts.snapshots, opts.snapshotRetention, time.Now(), false)
+	if err != nil {
+		return fmt.Errorf("Error pruning snapshots: %w", err)
+	}
+	if len(removed) > 0 {
+		progress.Printf("pruned %d snapshots\n", len(removed))
+	}
+
+	return nil
+}
+
+// resolveOutputs expands the output path templates of the selected formats
+// and refuses formats that would be written to the same path.
+func resolveOutputs(opts *options, now time.Time) ([]output, error) {
+	outputs := make([]output, len(opts.formats))
+	paths := map[string]string{}
+
+	for i, f := range opts.formats {
+		pathTemplate := opts.output
+		if p, ok := opts.outputPaths[f.name]; ok {
+			pathTemplate = p
+		}
+		path, err := outputPath(pathTemplate, f, opts.org, now)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := paths[path]; ok && path == stdoutPath {
+			return nil, fmt.Errorf("Formats %s and %s would both be written to stdout, select only one format", other, f.name)
+		}
+		if other, ok := paths[path]; ok {
+			return nil, fmt.Errorf("Formats %s and %s would both be written to %s, use {{.Format}} or {{.Name}} in --output", other, f.name, path)
+		}
+		paths[path] = f.name
+		outputs[i] = output{format: f, path: path}
+	}
+
+	return outputs, nil
+}
+
+// renderOutputs encodes all selected formats concurrently. Encoders only
+// read teams, so they can share it. The outputs are returned in the order
+// the formats were selected in.
+func renderOutputs(ctx context.Context, teams []Team, opts *options) ([]output, error) {
+	outputs, err := resolveOutputs(opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var g errgroup.Group
+
+	for i := range outputs {
+		o := &outputs[i]
+		g.Go(func() error {
+			data, err := o.format.encode(ctx, teams, opts)
+			if err != nil {
+				return fmt.Errorf("Error encoding %s output: %w", o.format.name, err)
+			}
+
+			if opts.query != nil {
+				data, err = applyQuery(opts.query, o.format, data)
+				if err != nil {
+					return err
+				}
+			}
+
+			o.data = data
+			return nil
+		})
+	}
+
+	err = g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+// writeFileIfChanged writes data to path unless the file already has
+// exactly that content, and reports whether it wrote.
+func writeFileIfChanged(path string, data []byte, perms filePermissions) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && sha256.Sum256(existing) == sha256.Sum256(data) {
+		return false, nil
+	}
+
+	// Templated paths like {{.Org}}/teams-graph.json may point to
+	// directories that don't exist yet.
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return false, err
+	}
+
+	err = perms.writeFile(path, data)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// staleOutputs renders every selected format and returns the paths whose
+// content on disk differs from what would be written, without writing.
+func staleOutputs(ctx context.Context, teams []Team, opts *options) ([]string, error) {
+	outputs, err := renderOutputs(ctx, teams, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := []string{}
+	for _, o := range outputs {
+		existing, err := os.ReadFile(o.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("Error reading %s file: %w", o.format.name, err)
+		}
+		if err != nil || sha256.Sum256(existing) != sha256.Sum256(o.data) {
+			stale = append(stale, o.path)
+		}
+	}
+
+	return stale, nil
+}
+
+// writeOutputs stores a snapshot if configured and writes every selected
+// format whose content changed. It returns the paths that were written.
+func writeOutputs(ctx context.Context, teams []Team, opts *options) ([]string, error) {
+	err := storeSnapshot(ctx, teams, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := renderOutputs(ctx, teams, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	changedPaths := []string{}
+
+	for _, o := range outputs {
+		if opts.encryption.enabled() {
+			o.data, err = opts.encryption.encrypt(o.data)
+			if err != nil {
+				return nil, fmt.Errorf("Error encrypting %s output: %w", o.format.name, err)
+			}
+			if o.path != stdoutPath {
+				o.path += opts.encryption.extension()
+			}
+		}
+
+		if o.path == stdoutPath {
+			_, err := os.Stdout.Write(o.data)
+			if err != nil {
+				return nil, fmt.Errorf("Error writing %s output to stdout: %w", o.format.name, err)
+			}
+			changedPaths = append(changedPaths, o.path)
+			continue
+		}
+
+		changed, err := writeFileIfChanged(o.path, o.data, opts.perms)
+		if err != nil {
+			return nil, fmt.Errorf("Error writing %s file: %w", o.format.name, err)
+		}
+		err = writeAttestations(o, opts, changed)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			progress.Printf("writing data to %s (changed)\n", o.path)
+			changedPaths = append(changedPaths, o.path)
+		} else {
+			progress.Printf("skipping %s (unchanged)\n", o.path)
+		}
+	}
+
+	return changedPaths, nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/giantswarm/org-vis/pkg/graphalg"
+)
+
+type Node struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"`
+	MemberCount *int     `json:"member_count,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	OnCall      []string `json:"on_call,omitempty"`
+
+	DiscussionPosts *int `json:"discussion_posts,omitempty"`
+	// IdPGroups is only set when team sync was queried. An empty list marks
+	// a hand-curated team.
+	IdPGroups  *[]string `json:"idp_groups,omitempty"`
+	IdPManaged *bool     `json:"idp_managed,omitempty"`
+	// Health is the fraction of the team's repositories with CODEOWNERS and
+	// branch protection.
+	Health *float64 `json:"health,omitempty"`
+	// ReviewLatency is the median time to first review in the team's
+	// repositories, in hours.
+	ReviewLatency *float64 `json:"review_latency_hours,omitempty"`
+}
+
+const (
+	// edgeOverlap links teams that share members.
+	edgeOverlap = "overlap"
+	// edgeHierarchy points from a parent team to a child team.
+	edgeHierarchy = "hierarchy"
+	// edgeChildOf points from a child team to its parent team, with
+	// --child-of-edges.
+	edgeChildOf = "child-of"
+	// edgeOwnership links a team to a project it works on or a repository it
+	// has access to.
+	edgeOwnership = "ownership"
+	// edgeMember points from a person to a team they are a member of, with
+	// --people.
+	edgeMember = "member"
+)
+
+// personType is the node type of team members with --people.
+const personType = "person"
+
+func graphPersonName(org, login string) string {
+	return org + "." + personType + "." + login
+}
+
+type Edge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Kind     string `json:"kind"`
+	Weight   int    `json:"weight"`
+	Directed bool   `json:"directed"`
+	// Members are the members both ends of an overlap edge share, unless
+	// in privacy mode.
+	Members []string `json:"members,omitempty"`
+	// Jaccard is the number of shared members of an overlap edge relative
+	// to the members of both teams, with --edge-jaccard. Unlike the weight
+	// it doesn't favor large teams.
+	Jaccard *float64 `json:"jaccard,omitempty"`
+}
+
+func (e Edge) connects(kind, source, target string) bool {
+	if e.Kind != kind {
+		return false
+	}
+	return e.Source == source && e.Target == target || !e.Directed && e.Source == target && e.Target == source
+}
+
+// Graph is the org graph. It keeps nodes and edges in insertion order,
+// which is the order they are encoded in.
+type Graph struct {
+	nodes []Node
+	index map[string]int
+	edges []Edge
+}
+
+func NewGraph() *Graph {
+	return &Graph{index: map[string]int{}}
+}
+
+// AddNode adds n, or replaces the node with the same name.
+func (g *Graph) AddNode(n Node) {
+	if i, ok := g.index[n.Name]; ok {
+		g.nodes[i] = n
+		return
+	}
+	g.index[n.Name] = len(g.nodes)
+	g.nodes = append(g.nodes, n)
+}
+
+// AddEdge adds an edge between two existing nodes. Adding an edge of the
+// same kind twice is a no-op.
+func (g *Graph) AddEdge(e Edge) error {
+	if _, ok := g.index[e.Source]; !ok {
+		return fmt.Errorf("Unknown node '%s'", e.Source)
+	}
+	if _, ok := g.index[e.Target]; !ok {
+		return fmt.Errorf("Unknown node '%s'", e.Target)
+	}
+	for _, existing := range g.edges {
+		if existing.connects(e.Kind, e.Source, e.Target) || e.connects(existing.Kind, existing.Source, existing.Target) {
+			return nil
+		}
+	}
+
+	g.edges = append(g.edges, e)
+	return nil
+}
+
+func (g *Graph) Node(name string) (Node, bool) {
+	i, ok := g.index[name]
+	if !ok {
+		return Node{}, false
+	}
+	return g.nodes[i], true
+}
+
+func (g *Graph) Nodes() []Node {
+	return append([]Node{}, g.nodes...)
+}
+
+func (g *Graph) Len() int {
+	return len(g.nodes)
+}
+
+func (g *Graph) Edges() []Edge {
+	return append([]Edge{}, g.edges...)
+}
+
+// Neighbors returns the sorted names of all nodes connected to name,
+// regardless of the edge direction and kind.
+func (g *Graph) Neighbors(name string) []string {
+	neighbors := []string{}
+	for _, e := range g.edges {
+		var other string
+		switch name {
+		case e.Source:
+			other = e.Target
+		case e.Target:
+			other = e.Source
+		default:
+			continue
+		}
+		if !contains(neighbors, other) {
+			neighbors = append(neighbors, other)
+		}
+	}
+	sort.Strings(neighbors)
+	return neighbors
+}
+
+// Filter returns a graph with the nodes keep returns true for. Edges to
+// dropped nodes are removed.
+func (g *Graph) Filter(keep func(Node) bool) *Graph {
+	filtered := NewGraph()
+	for _, n := range g.nodes {
+		if keep(n) {
+			filtered.AddNode(n)
+		}
+	}
+	for _, e := range g.edges {
+		_ = filtered.AddEdge(e)
+	}
+	return filtered
+}
+
+// Merge returns a graph with the nodes of g and other. Nodes in other
+// replace nodes of the same name, but keep the edges of both.
+func (g *Graph) Merge(other *Graph) *Graph {
+	merged := NewGraph()
+	for _, n := range append(g.Nodes(), other.nodes...) {
+		merged.AddNode(n)
+	}
+	for _, e := range append(g.Edges(), other.edges...) {
+		_ = merged.AddEdge(e)
+	}
+	return merged
+}
+
+// pruneImpliedEdges removes the edges of kind whose ends are also connected
+// through a path of edges of kind with a higher weight, regardless of their
+// direction.
+func (g *Graph) pruneImpliedEdges(kind string) {
+	indices := []int{}
+	weighted := []graphalg.WeightedEdge{}
+	for i, e := range g.edges {
+		if e.Kind == kind {
+			indices = append(indices, i)
+			weighted = append(weighted, graphalg.WeightedEdge{A: e.Source, B: e.Target, Weight: e.Weight})
+		}
+	}
+
+	drop := map[int]bool{}
+	for _, i := range graphalg.ImpliedEdges(weighted) {
+		drop[indices[i]] = true
+	}
+
+	edges := []Edge{}
+	for i, e := range g.edges {
+		if !drop[i] {
+			edges = append(edges, e)
+		}
+	}
+	g.edges = edges
+}
+
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}{Nodes: g.Nodes(), Edges: g.Edges()})
+}
+
+const (
+	edgeTeamToGroup = "team-to-group"
+	edgeGroupToTeam = "group-to-team"
+	edgeUndirected  = "undirected"
+)
+
+func edgeDirectionFlag(direction *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case edgeTeamToGroup, edgeGroupToTeam, edgeUndirected:
+			*direction = value
+			return nil
+		}
+		return fmt.Errorf("expected one of %s, %s or %s, got '%s'", edgeTeamToGroup, edgeGroupToTeam, edgeUndirected, value)
+	}
+}
+
+// membershipEdge links a primary team to a group its members are also in,
+// which is a team, sig, wg or project, in the configured direction.
+func membershipEdge(direction string, e Edge) Edge {
+	switch direction {
+	case edgeGroupToTeam:
+		e.Source, e.Target = e.Target, e.Source
+		e.Directed = true
+	case edgeUndirected:
+		e.Directed = false
+	default:
+		e.Directed = true
+	}
+	return e
+}
+
+func toGraph(teams []Team, opts *options) (*Graph, error) {
+	types := opts.taxonomy
+	minShared := opts.minSharedMembers
+	if minShared < 1 {
+		minShared = 1
+	}
+	g := NewGraph()
+
+	for _, team := range teams {
+		name, teamType, err := types.graphTeamName(opts.org, team.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		memberCount := len(team.Members)
+		node := Node{Name: name, Type: teamType.Key, MemberCount: &memberCount, Labels: team.Labels, OnCall: team.OnCall, DiscussionPosts: team.DiscussionPosts, Health: team.Health, ReviewLatency: team.ReviewLatency}
+		if team.IdPGroups != nil {
+			managed := len(*team.IdPGroups) > 0
+			node.IdPGroups = team.IdPGroups
+			node.IdPManaged = &managed
+		}
+		g.AddNode(node)
+	}
+
+	projects := []string{}
+	for _, team := range teams {
+		for _, project := range team.Projects {
+			if !contains(projects, project) {
+				projects = append(projects, project)
+			}
+		}
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		g.AddNode(Node{Name: graphProjectName(opts.org, project)})
+	}
+
+	if opts.people {
+		logins := []string{}
+		for _, team := range teams {
+			logins = union(logins, team.Members)
+		}
+		for _, login := range logins {
+			g.AddNode(Node{Name: graphPersonName(opts.org, login), Type: personType})
+		}
+	}
+
+	// Only primary teams link to the teams they share at least
+	// --min-shared-members members with. With --people, members link to
+	// their teams instead.
+	for _, teamA := range teams {
+		nameA, typeA, _ := types.graphTeamName(opts.org, teamA.Name)
+		if opts.people {
+			for _, login := range teamA.Members {
+				g.AddEdge(Edge{Source: graphPersonName(opts.org, login), Target: nameA, Kind: edgeMember, Weight: 1, Directed: true})
+			}
+		} else if typeA.Primary {
+			for _, teamB := range teams {
+				nameB, _, _ := types.graphTeamName(opts.org, teamB.Name)
+				shared := sharedMembers(teamA, teamB)
+				if nameA != nameB && len(shared) >= minShared {
+					edge := Edge{Source: nameA, Target: nameB, Kind: edgeOverlap, Weight: len(shared), Members: shared}
+					if opts.privacyMode {
+						edge.Members = nil
+					}
+					if opts.edgeJaccard {
+						similarity := math.Round(jaccard(teamA, teamB)*1000) / 1000
+						edge.Jaccard = &similarity
+					}
+					g.AddEdge(membershipEdge(opts.edgeDirection, edge))
+				}
+			}
+		}
+		for _, project := range teamA.Projects {
+			g.AddEdge(membershipEdge(opts.edgeDirection, Edge{Source: nameA, Target: graphProjectName(opts.org, project), Kind: edgeOwnership, Weight: 1}))
+		}
+	}
+
+	if opts.pruneImpliedOverlaps {
+		g.pruneImpliedEdges(edgeOverlap)
+	}
+
+	for _, team := range teams {
+		if team.Parent == nil {
+			continue
+		}
+		for _, parent := range teams {
+			if parent.Slug == team.Parent.Slug {
+				parentName, _, _ := types.graphTeamName(opts.org, parent.Name)
+				childName, _, _ := types.graphTeamName(opts.org, team.Name)
+				g.AddEdge(Edge{Source: parentName, Target: childName, Kind: edgeHierarchy, Weight: 1, Directed: true})
+				if opts.childOfEdges {
+					g.AddEdge(Edge{Source: childName, Target: parentName, Kind: edgeChildOf, Weight: 1, Directed: true})
+				}
+			}
+		}
+	}
+
+	if opts.repoTopology {
+		addRepoTopology(g, teams, opts.repoRelations, opts)
+	}
+
+	if opts.extra != nil {
+		opts.extra.addTo(g, opts)
+	}
+
+	return g, nil
+}
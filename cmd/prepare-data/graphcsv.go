@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The nodes-csv and edges-csv formats write the graph as a node list and an
+// edge list, for spreadsheets, pandas and BI tools. Columns are named like
+// the fields of the graph format; lists are joined with spaces, and missing
+// attributes are left empty.
+
+func csvInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func csvFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func csvBool(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*value)
+}
+
+func writeCSV(name string, header []string, records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err := w.Write(header)
+	if err != nil {
+		return nil, fmt.Errorf("Error writing %s csv header: %w", name, err)
+	}
+
+	for _, record := range records {
+		err = w.Write(record)
+		if err != nil {
+			return nil, fmt.Errorf("Error writing %s csv row: %w", name, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("Error writing %s csv: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeNodesCSV(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	records := [][]string{}
+	for _, n := range graph.Nodes() {
+		records = append(records, []string{
+			n.Name,
+			n.Type,
+			csvInt(n.MemberCount),
+			strings.Join(n.Labels, " "),
+			strings.Join(n.OnCall, " "),
+			csvInt(n.DiscussionPosts),
+			csvBool(n.IdPManaged),
+			csvFloat(n.Health),
+			csvFloat(n.ReviewLatency),
+		})
+	}
+
+	header := []string{"name", "type", "member_count", "labels", "on_call", "discussion_posts", "idp_managed", "health", "review_latency_hours"}
+	return writeCSV("nodes", header, records)
+}
+
+func encodeEdgesCSV(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	records := [][]string{}
+	for _, e := range graph.Edges() {
+		records = append(records, []string{
+			e.Source,
+			e.Target,
+			e.Kind,
+			strconv.Itoa(e.Weight),
+			strconv.FormatBool(e.Directed),
+			strings.Join(e.Members, " "),
+			csvFloat(e.Jaccard),
+		})
+	}
+
+	header := []string{"source", "target", "kind", "weight", "directed", "members", "jaccard"}
+	return writeCSV("edges", header, records)
+}
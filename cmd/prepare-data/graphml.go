@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Directed bool          `xml:"directed,attr"`
+	Data     []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys declares the node and edge attributes, named like the
+// fields of the graph format.
+var graphMLKeys = []graphMLKey{
+	{ID: "type", For: "node", Name: "type", Type: "string"},
+	{ID: "member_count", For: "node", Name: "member_count", Type: "int"},
+	{ID: "labels", For: "node", Name: "labels", Type: "string"},
+	{ID: "on_call", For: "node", Name: "on_call", Type: "string"},
+	{ID: "discussion_posts", For: "node", Name: "discussion_posts", Type: "int"},
+	{ID: "idp_managed", For: "node", Name: "idp_managed", Type: "boolean"},
+	{ID: "health", For: "node", Name: "health", Type: "double"},
+	{ID: "review_latency_hours", For: "node", Name: "review_latency_hours", Type: "double"},
+	{ID: "kind", For: "edge", Name: "kind", Type: "string"},
+	{ID: "weight", For: "edge", Name: "weight", Type: "int"},
+	{ID: "members", For: "edge", Name: "members", Type: "string"},
+	{ID: "jaccard", For: "edge", Name: "jaccard", Type: "double"},
+}
+
+// encodeGraphML writes the graph as GraphML, for yEd, Gephi and other
+// graph tools. Lists are joined with commas.
+func encodeGraphML(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		// Every edge states its direction, as only some kinds are directed.
+		Graph: graphMLGraph{ID: opts.org, EdgeDefault: "undirected", Nodes: []graphMLNode{}, Edges: []graphMLEdge{}},
+	}
+
+	for _, n := range graph.Nodes() {
+		node := graphMLNode{ID: n.Name}
+		if n.Type != "" {
+			node.Data = append(node.Data, graphMLData{Key: "type", Value: n.Type})
+		}
+		if n.MemberCount != nil {
+			node.Data = append(node.Data, graphMLData{Key: "member_count", Value: strconv.Itoa(*n.MemberCount)})
+		}
+		if len(n.Labels) > 0 {
+			node.Data = append(node.Data, graphMLData{Key: "labels", Value: strings.Join(n.Labels, ",")})
+		}
+		if len(n.OnCall) > 0 {
+			node.Data = append(node.Data, graphMLData{Key: "on_call", Value: strings.Join(n.OnCall, ",")})
+		}
+		if n.DiscussionPosts != nil {
+			node.Data = append(node.Data, graphMLData{Key: "discussion_posts", Value: strconv.Itoa(*n.DiscussionPosts)})
+		}
+		if n.IdPManaged != nil {
+			node.Data = append(node.Data, graphMLData{Key: "idp_managed", Value: strconv.FormatBool(*n.IdPManaged)})
+		}
+		if n.Health != nil {
+			node.Data = append(node.Data, graphMLData{Key: "health", Value: strconv.FormatFloat(*n.Health, 'f', -1, 64)})
+		}
+		if n.ReviewLatency != nil {
+			node.Data = append(node.Data, graphMLData{Key: "review_latency_hours", Value: strconv.FormatFloat(*n.ReviewLatency, 'f', -1, 64)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+
+	for _, e := range graph.Edges() {
+		edge := graphMLEdge{Source: e.Source, Target: e.Target, Directed: e.Directed}
+		edge.Data = append(edge.Data,
+			graphMLData{Key: "kind", Value: e.Kind},
+			graphMLData{Key: "weight", Value: strconv.Itoa(e.Weight)},
+		)
+		if len(e.Members) > 0 {
+			edge.Data = append(edge.Data, graphMLData{Key: "members", Value: strings.Join(e.Members, ",")})
+		}
+		if e.Jaccard != nil {
+			edge.Data = append(edge.Data, graphMLData{Key: "jaccard", Value: strconv.FormatFloat(*e.Jaccard, 'f', -1, 64)})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling graphml: %w", err)
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
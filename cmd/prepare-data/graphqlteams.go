@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+const (
+	apiREST    = "rest"
+	apiGraphQL = "graphql"
+)
+
+func apiFlag(api *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case apiREST, apiGraphQL:
+			*api = value
+			return nil
+		}
+		return fmt.Errorf("expected one of %s or %s, got '%s'", apiREST, apiGraphQL, value)
+	}
+}
+
+const teamsQuery = `query($org: String!, $after: String, $role: TeamMemberRole, $teams: Int!, $members: Int!) {
+  rateLimit { cost remaining resetAt }
+  organization(login: $org) {
+    teams(first: $teams, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        slug
+        privacy
+        parentTeam { name slug }
+        members(first: $members, role: $role) {
+          pageInfo { hasNextPage endCursor }
+          nodes { login }
+        }
+      }
+    }
+  }
+}`
+
+// graphQLMaxNodes is the most nodes GitHub lets a single query return.
+const graphQLMaxNodes = 500000
+
+// graphQLBatch is how many teams, and members of each of them, one query of
+// fetchTeamsGraphQL asks for.
+type graphQLBatch struct {
+	teams   int
+	members int
+}
+
+var defaultGraphQLBatch = graphQLBatch{teams: 100, members: 100}
+
+// nodes is the most nodes a query of the batch can return, which GitHub
+// checks against graphQLMaxNodes before running it.
+func (b graphQLBatch) nodes() int {
+	return b.teams + b.teams*b.members
+}
+
+// cost estimates the rate limit points of a query the way GitHub does: the
+// number of requests to fill every connection, one for the teams and one per
+// team for its members, divided by 100.
+func (b graphQLBatch) cost() int {
+	cost := (1 + b.teams + 50) / 100
+	if cost < 1 {
+		return 1
+	}
+	return cost
+}
+
+// shrink halves the teams per query, then the members per team. ok is false
+// when the batch can't get any smaller.
+func (b graphQLBatch) shrink() (graphQLBatch, bool) {
+	switch {
+	case b.teams > 1:
+		b.teams /= 2
+	case b.members > 1:
+		b.members /= 2
+	default:
+		return b, false
+	}
+	return b, true
+}
+
+// tooExpensive reports whether a query failed because of its size, either
+// exceeding the node limit or timing out on GitHub's side.
+func tooExpensive(err error) bool {
+	var queryErr *github.QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.HasType("MAX_NODE_LIMIT_EXCEEDED")
+	}
+	var statusErr *github.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusBadGateway || statusErr.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+const teamMembersQuery = `query($org: String!, $slug: String!, $after: String, $role: TeamMemberRole) {
+  organization(login: $org) {
+    team(slug: $slug) {
+      members(first: 100, after: $after, role: $role) {
+        pageInfo { hasNextPage endCursor }
+        nodes { login }
+      }
+    }
+  }
+}`
+
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type graphQLMembers struct {
+	PageInfo graphQLPageInfo `json:"pageInfo"`
+	Nodes    []struct {
+		Login string `json:"login"`
+	} `json:"nodes"`
+}
+
+func (m graphQLMembers) logins() []string {
+	logins := []string{}
+	for _, node := range m.Nodes {
+		logins = append(logins, node.Login)
+	}
+	return logins
+}
+
+// graphQLRole maps a role filter to the TeamMemberRole enum, nil for all.
+func graphQLRole(role string) interface{} {
+	if role == roleAll {
+		return nil
+	}
+	return strings.ToUpper(role)
+}
+
+// fetchTeamsGraphQL is fetchTeams with the teams and the first 100 members
+// of each team fetched in one GraphQL query per 100 teams. Only teams with
+// more members need further queries. Queries that are too expensive for
+// GitHub are retried in smaller batches.
+func fetchTeamsGraphQL(ctx context.Context, org string, filter teamFilter) ([]Team, error) {
+	progress.Println("fetching teams with graphql")
+
+	role := graphQLRole(filter.role())
+	relevantTeams := []Team{}
+	var after interface{}
+	batch := defaultGraphQLBatch
+	queries, points := 0, 0
+
+	for {
+		var data struct {
+			RateLimit struct {
+				Cost      int       `json:"cost"`
+				Remaining int       `json:"remaining"`
+				ResetAt   time.Time `json:"resetAt"`
+			} `json:"rateLimit"`
+			Organization struct {
+				Teams struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Name       string         `json:"name"`
+						Slug       string         `json:"slug"`
+						Privacy    string         `json:"privacy"`
+						ParentTeam *TeamRef       `json:"parentTeam"`
+						Members    graphQLMembers `json:"members"`
+					} `json:"nodes"`
+				} `json:"teams"`
+			} `json:"organization"`
+		}
+
+		for batch.nodes() > graphQLMaxNodes {
+			batch, _ = batch.shrink()
+		}
+
+		variables := map[string]interface{}{"org": org, "after": after, "role": role, "teams": batch.teams, "members": batch.members}
+		err := github.GraphQL(ctx, teamsQuery, variables, &data)
+		if tooExpensive(err) {
+			smaller, ok := batch.shrink()
+			if ok {
+				progress.Printf("graphql query for %d teams with %d members each was too expensive, retrying with %d and %d\n", batch.teams, batch.members, smaller.teams, smaller.members)
+				batch = smaller
+				continue
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching teams: %w", err)
+		}
+		queries++
+		points += data.RateLimit.Cost
+
+		// Wait for the reset rather than failing halfway through the teams.
+		if data.RateLimit.Remaining < batch.cost() {
+			wait := time.Until(data.RateLimit.ResetAt) + time.Second
+			progress.Printf("graphql rate limit exhausted, waiting %s\n", wait.Round(time.Second))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("Error fetching teams: %w", ctx.Err())
+			}
+		}
+
+		for _, node := range data.Organization.Teams.Nodes {
+			// The GraphQL API calls closed teams VISIBLE.
+			privacy := privacyClosed
+			if node.Privacy == "SECRET" {
+				privacy = privacySecret
+			}
+			team := filter.names.normalize(Team{Name: node.Name, Slug: node.Slug, Privacy: privacy, Parent: node.ParentTeam})
+			if !filter.includes(team) {
+				continue
+			}
+
+			team.Members = node.Members.logins()
+			if node.Members.PageInfo.HasNextPage {
+				members, err := fetchTeamMembersGraphQL(ctx, org, team.Slug, role, node.Members.PageInfo.EndCursor)
+				if err != nil {
+					return nil, fmt.Errorf("Error fetching team members for slug %s: %w", team.Slug, err)
+				}
+				team.Members = append(team.Members, members...)
+			}
+			relevantTeams = append(relevantTeams, team)
+		}
+
+		pageInfo := data.Organization.Teams.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	progress.Printf("fetched teams with %d graphql queries costing %d points\n", queries, points)
+
+	return relevantTeams, nil
+}
+
+// fetchTeamMembersGraphQL fetches the remaining members of a team after the
+// cursor of the first page.
+func fetchTeamMembersGraphQL(ctx context.Context, org, slug string, role interface{}, after string) ([]string, error) {
+	progress.Printf("fetching more team members for '%s'\n", slug)
+
+	members := []string{}
+	for after != "" {
+		var data struct {
+			Organization struct {
+				Team struct {
+					Members graphQLMembers `json:"members"`
+				} `json:"team"`
+			} `json:"organization"`
+		}
+
+		err := github.GraphQL(ctx, teamMembersQuery, map[string]interface{}{"org": org, "slug": slug, "after": after, "role": role}, &data)
+		if err != nil {
+			return nil, err
+		}
+
+		page := data.Organization.Team.Members
+		members = append(members, page.logins()...)
+
+		after = ""
+		if page.PageInfo.HasNextPage {
+			after = page.PageInfo.EndCursor
+		}
+	}
+
+	return members, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// repoHygiene is whether a repository has a CODEOWNERS file and a
+// protected default branch.
+type repoHygiene struct {
+	codeowners bool
+	protected  bool
+}
+
+func (h repoHygiene) covered() bool {
+	return h.codeowners && h.protected
+}
+
+// fetchBranchProtected returns whether branch is protected. Reading the
+// protection rules themselves needs admin access, the flag does not.
+func fetchBranchProtected(ctx context.Context, org, repo, branch string) (bool, error) {
+	branchBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/repos/%s/%s/branches/%s", github.APIURL, org, repo, branch))
+	var notFound *github.NotFoundError
+	if errors.As(err, &notFound) {
+		// Empty repositories have no default branch yet.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Error fetching branch %s of repository %s: %w", branch, repo, err)
+	}
+
+	var b struct {
+		Protected bool `json:"protected"`
+	}
+
+	err = json.Unmarshal(branchBytes, &b)
+	if err != nil {
+		return false, fmt.Errorf("Error parsing branch %s of repository %s: %w", branch, repo, err)
+	}
+
+	return b.Protected, nil
+}
+
+func fetchRepoHygiene(ctx context.Context, org string, repo Repository) (repoHygiene, error) {
+	progress.Printf("fetching CODEOWNERS and branch protection for '%s'\n", repo.Name)
+
+	path, _, err := fetchCodeowners(ctx, org, repo.Name)
+	if err != nil {
+		return repoHygiene{}, err
+	}
+
+	protected := false
+	if repo.DefaultBranch != "" {
+		protected, err = fetchBranchProtected(ctx, org, repo.Name, repo.DefaultBranch)
+		if err != nil {
+			return repoHygiene{}, err
+		}
+	}
+
+	return repoHygiene{codeowners: path != "", protected: protected}, nil
+}
+
+// attachOwnershipHealth sets the health of each team to the fraction of its
+// unarchived repositories that have both a CODEOWNERS file and a protected
+// default branch. Teams without repositories, or whose repositories can't
+// be read, are left without the attribute.
+func attachOwnershipHealth(ctx context.Context, org string, teams []Team) {
+	// Teams share repositories, so each is only checked once.
+	hygiene := map[string]repoHygiene{}
+
+	for i := range teams {
+		repos, err := fetchTeamRepos(ctx, org, teams[i].Slug)
+		if err != nil {
+			log.Printf("skipping ownership health for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+
+		total, covered := 0, 0
+		for _, repo := range repos {
+			if repo.Archived {
+				continue
+			}
+			h, ok := hygiene[repo.Name]
+			if !ok {
+				h, err = fetchRepoHygiene(ctx, org, repo)
+				if err != nil {
+					break
+				}
+				hygiene[repo.Name] = h
+			}
+			total++
+			if h.covered() {
+				covered++
+			}
+		}
+		if err != nil {
+			log.Printf("skipping ownership health for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+		if total == 0 {
+			continue
+		}
+
+		// Rounded to whole percents.
+		health := math.Round(float64(covered)/float64(total)*100) / 100
+		teams[i].Health = &health
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+)
+
+type Heatmap struct {
+	Names  []string    `json:"names"`
+	Matrix [][]float64 `json:"matrix"`
+}
+
+func jaccard(a, b Team) float64 {
+	shared := len(sharedMembers(a, b))
+	union := len(a.Members) + len(b.Members) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// clusterOrder orders teams by average-linkage agglomerative clustering on
+// the similarity matrix, so that similar teams end up next to each other.
+func clusterOrder(similarity [][]float64) []int {
+	clusters := [][]int{}
+	for i := range similarity {
+		clusters = append(clusters, []int{i})
+	}
+
+	average := func(a, b []int) float64 {
+		sum := 0.0
+		for _, i := range a {
+			for _, j := range b {
+				sum += similarity[i][j]
+			}
+		}
+		return sum / float64(len(a)*len(b))
+	}
+
+	for len(clusters) > 1 {
+		bestA, bestB, best := 0, 1, -1.0
+		for a := range clusters {
+			for b := a + 1; b < len(clusters); b++ {
+				if s := average(clusters[a], clusters[b]); s > best {
+					bestA, bestB, best = a, b, s
+				}
+			}
+		}
+
+		merged := append(append([]int{}, clusters[bestA]...), clusters[bestB]...)
+		clusters[bestA] = merged
+		clusters = append(clusters[:bestB], clusters[bestB+1:]...)
+	}
+
+	if len(clusters) == 0 {
+		return []int{}
+	}
+	return clusters[0]
+}
+
+func toHeatmap(teams []Team) *Heatmap {
+	similarity := make([][]float64, len(teams))
+	for i := range teams {
+		similarity[i] = make([]float64, len(teams))
+		for j := range teams {
+			similarity[i][j] = jaccard(teams[i], teams[j])
+		}
+	}
+
+	order := clusterOrder(similarity)
+
+	h := &Heatmap{Names: []string{}, Matrix: [][]float64{}}
+	for _, i := range order {
+		h.Names = append(h.Names, teams[i].Name)
+		row := []float64{}
+		for _, j := range order {
+			row = append(row, similarity[i][j])
+		}
+		h.Matrix = append(h.Matrix, row)
+	}
+
+	return h
+}
+
+func encodeHeatmap(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	return marshalIndented(toHeatmap(teams))
+}
+
+func encodeHeatmapCSV(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	h := toHeatmap(teams)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err := w.Write(append([]string{"team"}, h.Names...))
+	if err != nil {
+		return nil, fmt.Errorf("Error writing heatmap csv header: %w", err)
+	}
+
+	for i, row := range h.Matrix {
+		record := []string{h.Names[i]}
+		for _, value := range row {
+			record = append(record, strconv.FormatFloat(value, 'f', 4, 64))
+		}
+		err = w.Write(record)
+		if err != nil {
+			return nil, fmt.Errorf("Error writing heatmap csv row: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("Error writing heatmap csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// historyTemperature limits how far nodes move between two frames, relative
+// to the unit square of the layout, so that consecutive frames stay close.
+const historyTemperature = 0.03
+
+// History is a sequence of graph frames, one per snapshot, with positions
+// that can be animated between.
+type History struct {
+	Width  int            `json:"width"`
+	Height int            `json:"height"`
+	Frames []HistoryFrame `json:"frames"`
+}
+
+type HistoryFrame struct {
+	TakenAt time.Time     `json:"taken_at"`
+	Nodes   []HistoryNode `json:"nodes"`
+	Edges   []Edge        `json:"edges"`
+	Renames []TeamRename  `json:"renames,omitempty"`
+}
+
+type HistoryNode struct {
+	Node
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// knownTeams leaves out teams whose type isn't in the taxonomy anymore, as
+// the graph can't name them.
+func knownTeams(teams []Team, types taxonomy) []Team {
+	known := []Team{}
+	for _, team := range teams {
+		if _, ok := types.typeOf(team.Name); ok {
+			known = append(known, team)
+		}
+	}
+	return known
+}
+
+// toHistory lays out the graph of every snapshot, starting each frame from
+// the positions of the previous one. Renamed teams keep their position, and
+// teams appearing start where they would in a fresh layout. All frames are
+// fitted to size together.
+func toHistory(snapshots []Snapshot, opts *options, size imageSize) (*History, error) {
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("Need at least one snapshot for the history")
+	}
+
+	graphs := []*Graph{}
+	layouts := []map[string]point{}
+	renames := [][]TeamRename{}
+
+	var previous map[string]point
+	for i, snapshot := range snapshots {
+		snapshot.Teams = knownTeams(snapshot.Teams, opts.taxonomy)
+		g, err := toGraph(snapshot.Teams, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Error generating graph of snapshot taken at %s: %w", snapshot.TakenAt.Format(time.RFC3339), err)
+		}
+
+		frameRenames := []TeamRename{}
+		if i > 0 {
+			frameRenames = snapshotRenames(snapshots[i-1], snapshot)
+		}
+
+		positions := map[string]point{}
+		temperature := layoutTemperature
+		for _, n := range g.Nodes() {
+			positions[n.Name] = layoutStart(opts.org, n.Name)
+		}
+		if previous != nil {
+			temperature = historyTemperature
+			for name, p := range previous {
+				if _, ok := positions[name]; ok {
+					positions[name] = p
+				}
+			}
+			for _, rename := range frameRenames {
+				from, _, errFrom := opts.taxonomy.graphTeamName(opts.org, rename.From)
+				to, _, errTo := opts.taxonomy.graphTeamName(opts.org, rename.To)
+				if p, ok := previous[from]; ok && errFrom == nil && errTo == nil {
+					positions[to] = p
+				}
+			}
+		}
+		relaxLayout(g, positions, temperature)
+
+		// Copied, as relaxing the next frame changes its positions.
+		previous = map[string]point{}
+		for name, p := range positions {
+			previous[name] = p
+		}
+
+		graphs = append(graphs, g)
+		layouts = append(layouts, positions)
+		renames = append(renames, frameRenames)
+	}
+
+	history := &History{Width: size.width, Height: size.height, Frames: []HistoryFrame{}}
+	for i, layout := range fitLayouts(layouts, size, svgMargin) {
+		frame := HistoryFrame{TakenAt: snapshots[i].TakenAt, Nodes: []HistoryNode{}, Edges: graphs[i].Edges(), Renames: renames[i]}
+		for _, n := range graphs[i].Nodes() {
+			frame.Nodes = append(frame.Nodes, HistoryNode{Node: n, X: layout[n.Name].X, Y: layout[n.Name].Y})
+		}
+		history.Frames = append(history.Frames, frame)
+	}
+
+	return history, nil
+}
+
+// encodeHistory writes layout-stable graph frames of the stored snapshots,
+// for the frontend to animate how the org evolved.
+func encodeHistory(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.snapshotDir == "" {
+		return nil, fmt.Errorf("The history format requires --snapshot-dir")
+	}
+
+	snapshots, err := loadSnapshots(ctx, opts.snapshots, opts.since, opts.until)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := toHistory(snapshots, opts, opts.imageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalIndented(history)
+}
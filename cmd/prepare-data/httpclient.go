@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// httpClient sends the requests that don't go to the GitHub API, like
+// alert webhooks and release downloads.
+var httpClient = http.DefaultClient
+
+type httpOptions struct {
+	// proxy is used for all requests, HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// are used without it.
+	proxy string
+	// caBundle is a PEM file of CA certificates trusted in addition to the
+	// system ones.
+	caBundle string
+	// clientCert and clientKey are the PEM certificate and key presented
+	// for mutual TLS.
+	clientCert     string
+	clientKey      string
+	connectTimeout time.Duration
+	// debug logs every request, see debugTransport.
+	debug bool
+}
+
+func (o httpOptions) client() (*http.Client, error) {
+	if o.clientCert != "" && o.clientKey == "" || o.clientCert == "" && o.clientKey != "" {
+		return nil, fmt.Errorf("--client-cert and --client-key must be used together")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.proxy != "" {
+		proxy, err := url.Parse(o.proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL '%s'", o.proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if o.connectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: o.connectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = o.connectTimeout
+	}
+
+	if o.caBundle != "" || o.clientCert != "" {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if o.caBundle != "" {
+		pem, err := os.ReadFile(o.caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificates found in CA bundle '%s'", o.caBundle)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if o.clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.clientCert, o.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading client certificate: %w", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.debug {
+		return &http.Client{Transport: &debugTransport{next: transport}}, nil
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// configureHTTP makes all requests, to GitHub and elsewhere, use the client
+// configured by opts.
+func configureHTTP(opts httpOptions) error {
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	httpClient = client
+	github.Client = client
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// identityMap maps GitHub logins to identities in the company directory.
+// Values are either a user id or a full DN.
+type identityMap map[string]string
+
+func loadIdentityMap(path string) (identityMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading identity map '%s': %w", path, err)
+	}
+
+	identities := identityMap{}
+	err = yaml.Unmarshal(data, &identities)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing identity map '%s': %w", path, err)
+	}
+
+	// Logins are case-insensitive.
+	normalized := identityMap{}
+	for login, identity := range identities {
+		normalized[strings.ToLower(login)] = identity
+	}
+
+	return normalized, nil
+}
+
+// userDN returns the DN of login below people, falling back to the login as
+// user id when it isn't mapped.
+func (m identityMap) userDN(login, people string) string {
+	identity, ok := m[strings.ToLower(login)]
+	if !ok {
+		identity = login
+	}
+	if strings.Contains(identity, "=") {
+		return identity
+	}
+	return "uid=" + escapeDNValue(identity) + "," + people
+}
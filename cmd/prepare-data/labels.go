@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// conventionLabel returns the issue label owned by a team by convention,
+// i.e. "team/phoenix" for "team-phoenix" and "sig/docs" for "sig-docs".
+func conventionLabel(teamName string) (string, bool) {
+	parts := strings.SplitN(strings.ToLower(teamName), "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + parts[1], true
+}
+
+func labelMappingFlag(mappings map[string][]string) func(string) error {
+	return func(value string) error {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("expected label=team, got '%s'", value)
+		}
+		mappings[parts[1]] = append(mappings[parts[1]], parts[0])
+		return nil
+	}
+}
+
+// attachLabels records the issue labels each team owns, both by convention
+// and from the explicit label mappings, keyed by team name or slug.
+func attachLabels(teams []Team, convention bool, mappings map[string][]string) error {
+	known := map[string]bool{}
+
+	for i := range teams {
+		labels := []string{}
+		if convention {
+			if label, ok := conventionLabel(teams[i].Name); ok {
+				labels = append(labels, label)
+			}
+		}
+		for _, key := range []string{teams[i].Name, teams[i].Slug} {
+			known[key] = true
+			for _, label := range mappings[key] {
+				if !contains(labels, label) {
+					labels = append(labels, label)
+				}
+			}
+		}
+		sort.Strings(labels)
+		teams[i].Labels = labels
+	}
+
+	for key := range mappings {
+		if !known[key] {
+			return fmt.Errorf("Label mapping references unknown team '%s'", key)
+		}
+	}
+
+	return nil
+}
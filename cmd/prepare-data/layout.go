@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const layoutIterations = 300
+
+type point struct {
+	X, Y float64
+}
+
+// imageSize is the size of rendered images in pixels.
+type imageSize struct {
+	width, height int
+}
+
+func (s imageSize) String() string {
+	return fmt.Sprintf("%dx%d", s.width, s.height)
+}
+
+func imageSizeFlag(size *imageSize) func(string) error {
+	return func(value string) error {
+		parts := strings.Split(value, "x")
+		if len(parts) != 2 {
+			return fmt.Errorf("expected WIDTHxHEIGHT, got '%s'", value)
+		}
+		width, err := strconv.Atoi(parts[0])
+		if err != nil || width < 100 {
+			return fmt.Errorf("expected a width of at least 100 pixels, got '%s'", parts[0])
+		}
+		height, err := strconv.Atoi(parts[1])
+		if err != nil || height < 100 {
+			return fmt.Errorf("expected a height of at least 100 pixels, got '%s'", parts[1])
+		}
+		*size = imageSize{width: width, height: height}
+		return nil
+	}
+}
+
+// layoutStart returns the pseudo-random start position of a node in the
+// unit square. It is seeded by the org and the node name only, so a node
+// starts at the same place in every snapshot, whatever else changed, and
+// the layouts of consecutive snapshots stay visually close.
+func layoutStart(org, name string) point {
+	h := fnv.New64a()
+	h.Write([]byte(org + "/" + name))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	return point{X: rng.Float64(), Y: rng.Float64()}
+}
+
+// forceLayout places the nodes of g with the force-directed algorithm of
+// Fruchterman and Reingold, see relaxLayout. The positions are scaled to fit
+// size, leaving margin pixels free at the borders. The same graph always
+// gives the same layout.
+func forceLayout(g *Graph, org string, size imageSize, margin float64) map[string]point {
+	positions := map[string]point{}
+	for _, n := range g.Nodes() {
+		positions[n.Name] = layoutStart(org, n.Name)
+	}
+	relaxLayout(g, positions, layoutTemperature)
+
+	return fitLayouts([]map[string]point{positions}, size, margin)[0]
+}
+
+// layoutTemperature is the largest step a node takes at first, relative to
+// the unit square the layout starts in.
+const layoutTemperature = 0.1
+
+// relaxLayout moves the nodes of g from their positions towards a balance:
+// nodes repel each other, edges pull their ends together, heavier edges
+// more so, and a weak gravity keeps unconnected parts close. No step is
+// longer than temperature, which cools down over the iterations, so a
+// layout that is already balanced only changes a little.
+func relaxLayout(g *Graph, positions map[string]point, temperature float64) {
+	nodes := g.Nodes()
+	if len(nodes) == 0 {
+		return
+	}
+	index := map[string]int{}
+	current := make([]point, len(nodes))
+	for i, n := range nodes {
+		index[n.Name] = i
+		current[i] = positions[n.Name]
+	}
+
+	k := math.Sqrt(1 / float64(len(nodes)))
+
+	for iteration := 0; iteration < layoutIterations; iteration++ {
+		moves := make([]point, len(nodes))
+
+		for i := range current {
+			for j := i + 1; j < len(current); j++ {
+				dx, dy := current[i].X-current[j].X, current[i].Y-current[j].Y
+				distance := math.Max(math.Hypot(dx, dy), 0.001)
+				force := k * k / distance
+				moves[i].X += dx / distance * force
+				moves[i].Y += dy / distance * force
+				moves[j].X -= dx / distance * force
+				moves[j].Y -= dy / distance * force
+			}
+		}
+
+		for _, e := range g.Edges() {
+			i, j := index[e.Source], index[e.Target]
+			if i == j {
+				continue
+			}
+			dx, dy := current[i].X-current[j].X, current[i].Y-current[j].Y
+			distance := math.Max(math.Hypot(dx, dy), 0.001)
+			force := distance * distance / k * math.Log1p(float64(e.Weight))
+			moves[i].X -= dx / distance * force
+			moves[i].Y -= dy / distance * force
+			moves[j].X += dx / distance * force
+			moves[j].Y += dy / distance * force
+		}
+
+		for i := range current {
+			moves[i].X -= (current[i].X - 0.5) * k
+			moves[i].Y -= (current[i].Y - 0.5) * k
+
+			length := math.Hypot(moves[i].X, moves[i].Y)
+			if length > 0 {
+				step := math.Min(length, temperature)
+				current[i].X += moves[i].X / length * step
+				current[i].Y += moves[i].Y / length * step
+			}
+		}
+
+		temperature *= 0.98
+	}
+
+	for i, n := range nodes {
+		positions[n.Name] = current[i]
+	}
+}
+
+// fitLayouts scales and moves the positions of all layouts alike to fill
+// size within margin, keeping the aspect ratio. Fitting the frames of an
+// animation together keeps nodes that don't move in place.
+func fitLayouts(layouts []map[string]point, size imageSize, margin float64) []map[string]point {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, positions := range layouts {
+		for _, p := range positions {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+
+	width, height := float64(size.width)-2*margin, float64(size.height)-2*margin
+	scale := math.Inf(1)
+	if maxX > minX {
+		scale = width / (maxX - minX)
+	}
+	if maxY > minY {
+		scale = math.Min(scale, height/(maxY-minY))
+	}
+	if math.IsInf(scale, 1) {
+		scale = 0
+	}
+	// Center the layout in the direction it doesn't fill.
+	offsetX := margin + (width-(maxX-minX)*scale)/2
+	offsetY := margin + (height-(maxY-minY)*scale)/2
+
+	fitted := []map[string]point{}
+	for _, positions := range layouts {
+		layout := map[string]point{}
+		for name, p := range positions {
+			layout[name] = point{
+				X: math.Round((offsetX+(p.X-minX)*scale)*10) / 10,
+				Y: math.Round((offsetY+(p.Y-minY)*scale)*10) / 10,
+			}
+		}
+		fitted = append(fitted, layout)
+	}
+	return fitted
+}
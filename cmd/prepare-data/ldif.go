@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// escapeDNValue escapes an attribute value for use in a DN (RFC 4514).
+func escapeDNValue(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(",+\"\\<>;=", r),
+			r == '#' && i == 0,
+			r == ' ' && (i == 0 || i == len(value)-1):
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ldifSafe reports whether value can be written as is (RFC 2849), instead
+// of base64 encoded.
+func ldifSafe(value string) bool {
+	if value == "" {
+		return true
+	}
+	if value[0] == ' ' || value[0] == ':' || value[0] == '<' || value[len(value)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] == 0 || value[i] == '\n' || value[i] == '\r' || value[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLDIFAttribute(buf *bytes.Buffer, name, value string) {
+	if ldifSafe(value) {
+		fmt.Fprintf(buf, "%s: %s\n", name, value)
+		return
+	}
+	fmt.Fprintf(buf, "%s:: %s\n", name, base64.StdEncoding.EncodeToString([]byte(value)))
+}
+
+// encodeLDIF writes every team as a groupOfNames below ou=groups, with its
+// members mapped to DNs below ou=people through the identity map. Teams
+// without members are skipped, as groupOfNames requires a member.
+func encodeLDIF(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.privacyMode {
+		return nil, fmt.Errorf("The ldif format lists members and can't be used in privacy mode")
+	}
+	if opts.ldifBaseDN == "" {
+		return nil, fmt.Errorf("The ldif format requires --ldif-base-dn")
+	}
+
+	groups := "ou=groups," + opts.ldifBaseDN
+	people := "ou=people," + opts.ldifBaseDN
+
+	var buf bytes.Buffer
+	buf.WriteString("version: 1\n")
+
+	for _, team := range teams {
+		if len(team.Members) == 0 {
+			continue
+		}
+
+		buf.WriteString("\n")
+		writeLDIFAttribute(&buf, "dn", "cn="+escapeDNValue(team.Slug)+","+groups)
+		writeLDIFAttribute(&buf, "objectClass", "top")
+		writeLDIFAttribute(&buf, "objectClass", "groupOfNames")
+		writeLDIFAttribute(&buf, "cn", team.Slug)
+		writeLDIFAttribute(&buf, "description", team.Name)
+		for _, member := range team.Members {
+			writeLDIFAttribute(&buf, "member", opts.identities.userDN(member, people))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+)
+
+type Finding struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Repo    string `json:"repo,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+func (f Finding) String() string {
+	location := f.Repo
+	if f.Path != "" {
+		location += "/" + f.Path
+		if f.Line > 0 {
+			location += fmt.Sprintf(":%d", f.Line)
+		}
+	}
+	if location == "" {
+		return fmt.Sprintf("[%s] %s", f.Rule, f.Message)
+	}
+	return fmt.Sprintf("%s: [%s] %s", location, f.Rule, f.Message)
+}
+
+type lintRepository struct {
+	Repository
+	CodeownersPath  string
+	CodeownersRules []CodeownersRule
+}
+
+// lintInput is the org data lint rules run against. It is fetched lazily so
+// that rules not selected don't cost any API requests.
+type lintInput struct {
+	org string
+	// filter decides which teams are relevant, like for the graph.
+	filter    teamFilter
+	teams     []Team
+	repos     []lintRepository
+	teamRepos map[string]map[string]Permissions
+}
+
+func (in *lintInput) allTeams(ctx context.Context) ([]Team, error) {
+	if in.teams == nil {
+		teams, err := fetchAllTeams(ctx, in.org)
+		if err != nil {
+			return nil, err
+		}
+		in.teams = teams
+	}
+	return in.teams, nil
+}
+
+func (in *lintInput) repositories(ctx context.Context) ([]lintRepository, error) {
+	if in.repos == nil {
+		repos, err := fetchRepos(ctx, in.org)
+		if err != nil {
+			return nil, err
+		}
+
+		in.repos = []lintRepository{}
+		for _, repo := range repos {
+			if repo.Archived {
+				continue
+			}
+
+			progress.Printf("fetching CODEOWNERS for '%s'\n", repo.Name)
+			path, content, err := fetchCodeowners(ctx, in.org, repo.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			in.repos = append(in.repos, lintRepository{
+				Repository:      repo,
+				CodeownersPath:  path,
+				CodeownersRules: parseCodeowners(content),
+			})
+		}
+	}
+	return in.repos, nil
+}
+
+func (in *lintInput) teamPermissions(ctx context.Context, slug string) (map[string]Permissions, error) {
+	if in.teamRepos == nil {
+		in.teamRepos = map[string]map[string]Permissions{}
+	}
+	if permissions, ok := in.teamRepos[slug]; ok {
+		return permissions, nil
+	}
+
+	repos, err := fetchTeamRepos(ctx, in.org, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := map[string]Permissions{}
+	for _, repo := range repos {
+		permissions[repo.Name] = repo.Permissions
+	}
+	in.teamRepos[slug] = permissions
+
+	return permissions, nil
+}
+
+type lintRule struct {
+	name        string
+	description string
+	check       func(ctx context.Context, in *lintInput) ([]Finding, error)
+}
+
+var lintRules = []lintRule{
+	{
+		name:        "codeowners-teams",
+		description: "teams referenced in CODEOWNERS exist, are relevant and have push access to the repository",
+		check:       checkCodeownersTeams,
+	},
+	{
+		name:        "codeowners-coverage",
+		description: "every repository has a CODEOWNERS file referencing at least one team",
+		check:       checkCodeownersCoverage,
+	},
+	{
+		name:        "duplicate-team-names",
+		description: "no two team names differ only by case, hyphens, underscores or whitespace",
+		check:       checkDuplicateTeamNames,
+	},
+	{
+		name:        "codeowner-load",
+		description: "nobody is individually named in far more CODEOWNERS paths than the others",
+		check:       checkCodeownerLoad,
+	},
+}
+
+func checkCodeownersTeams(ctx context.Context, in *lintInput) ([]Finding, error) {
+	teams, err := in.allTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := in.repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teamsBySlug := map[string]Team{}
+	for _, team := range teams {
+		teamsBySlug[strings.ToLower(team.Slug)] = team
+	}
+
+	findings := []Finding{}
+
+	for _, repo := range repos {
+		for _, rule := range repo.CodeownersRules {
+			for _, owner := range rule.Owners {
+				org, slug, ok := codeownersTeam(owner)
+				if !ok {
+					continue
+				}
+
+				finding := Finding{Rule: "codeowners-teams", Repo: repo.Name, Path: repo.CodeownersPath, Line: rule.Line}
+
+				if !strings.EqualFold(org, in.org) {
+					finding.Message = fmt.Sprintf("%s references a team outside of the organization", owner)
+					findings = append(findings, finding)
+					continue
+				}
+
+				team, ok := teamsBySlug[strings.ToLower(slug)]
+				if !ok {
+					finding.Message = fmt.Sprintf("%s references a team that does not exist", owner)
+					findings = append(findings, finding)
+					continue
+				}
+
+				if !in.filter.includes(in.filter.names.normalize(team)) {
+					finding.Message = fmt.Sprintf("%s references team '%s' which the team filter leaves out, relevant teams start with %s", owner, team.Name, strings.Join(in.filter.Prefixes, ", "))
+					findings = append(findings, finding)
+				}
+
+				permissions, err := in.teamPermissions(ctx, team.Slug)
+				if err != nil {
+					return nil, err
+				}
+				if !permissions[repo.Name].Push {
+					finding.Message = fmt.Sprintf("%s has no push access to the repository", owner)
+					findings = append(findings, finding)
+				}
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+const (
+	codeownersOK              = "ok"
+	codeownersMissing         = "missing"
+	codeownersIndividualsOnly = "individuals-only"
+)
+
+// codeownersStatus classifies a repository's CODEOWNERS file and returns the
+// teams it references.
+func codeownersStatus(repo lintRepository) (string, []string) {
+	if repo.CodeownersPath == "" {
+		return codeownersMissing, nil
+	}
+
+	teams := []string{}
+	for _, rule := range repo.CodeownersRules {
+		for _, owner := range rule.Owners {
+			if _, _, ok := codeownersTeam(owner); ok && !contains(teams, owner) {
+				teams = append(teams, owner)
+			}
+		}
+	}
+	if len(teams) == 0 {
+		return codeownersIndividualsOnly, teams
+	}
+
+	return codeownersOK, teams
+}
+
+func checkCodeownersCoverage(ctx context.Context, in *lintInput) ([]Finding, error) {
+	repos, err := in.repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []Finding{}
+
+	for _, repo := range repos {
+		status, _ := codeownersStatus(repo)
+		switch status {
+		case codeownersMissing:
+			findings = append(findings, Finding{Rule: "codeowners-coverage", Repo: repo.Name, Message: "repository has no CODEOWNERS file"})
+		case codeownersIndividualsOnly:
+			findings = append(findings, Finding{Rule: "codeowners-coverage", Repo: repo.Name, Path: repo.CodeownersPath, Message: "CODEOWNERS only references individuals, not teams"})
+		}
+	}
+
+	return findings, nil
+}
+
+// normalizedTeamName is the name with case, hyphens, underscores and
+// whitespace ignored.
+func normalizedTeamName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+func checkDuplicateTeamNames(ctx context.Context, in *lintInput) ([]Finding, error) {
+	teams, err := in.allTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]string{}
+	keys := []string{}
+	for _, team := range teams {
+		key := normalizedTeamName(team.Name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], team.Name)
+	}
+
+	findings := []Finding{}
+
+	for _, key := range keys {
+		names := groups[key]
+		if len(names) < 2 {
+			continue
+		}
+
+		// Names only differing by spaces end up as the same graph node.
+		message := "teams %s have near-duplicate names"
+		graphNames := []string{}
+		for _, name := range names {
+			graphName := strings.ReplaceAll(name, " ", "")
+			if contains(graphNames, graphName) {
+				message = "teams %s have near-duplicate names and are merged in the graph"
+			}
+			graphNames = append(graphNames, graphName)
+		}
+
+		quoted := []string{}
+		for _, name := range names {
+			quoted = append(quoted, fmt.Sprintf("'%s'", name))
+		}
+		findings = append(findings, Finding{Rule: "duplicate-team-names", Message: fmt.Sprintf(message, strings.Join(quoted, ", "))})
+	}
+
+	return findings, nil
+}
+
+func writeCodeownersReport(ctx context.Context, path string, in *lintInput) error {
+	repos, err := in.repositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	err = w.Write([]string{"repository", "codeowners_path", "status", "teams"})
+	if err != nil {
+		return fmt.Errorf("Error writing codeowners report header: %w", err)
+	}
+
+	for _, repo := range repos {
+		status, teams := codeownersStatus(repo)
+		err = w.Write([]string{repo.Name, repo.CodeownersPath, status, strings.Join(teams, " ")})
+		if err != nil {
+			return fmt.Errorf("Error writing codeowners report row: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("Error writing codeowners report: %w", err)
+	}
+
+	log.Printf("writing codeowners report to %s\n", path)
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing codeowners report file: %w", err)
+	}
+
+	return nil
+}
+
+func ruleNames() []string {
+	names := []string{}
+	for _, rule := range lintRules {
+		names = append(names, rule.name)
+	}
+	return names
+}
+
+func runLint(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	rulesFlag := flags.String("rules", strings.Join(ruleNames(), ","), "comma-separated list of lint rules to run ("+strings.Join(ruleNames(), ", ")+")")
+	org := flags.String("org", orgFromEnv(), "organization to lint")
+	configPath := flags.String("config", "", "YAML config file whose filters decide which teams are relevant (default "+defaultConfigPath+" if it exists)")
+	reportFlag := flags.String("codeowners-report", "", "write a CSV report of the CODEOWNERS status of every repository to this file")
+	loadReportFlag := flags.String("codeowner-load-report", "", "write a CSV report of how many CODEOWNERS paths and repositories each person is individually named in to this file")
+	sarifFlag := flags.String("sarif", "", "also write the findings to this file as SARIF for GitHub code scanning, located at repo/path")
+	flags.Parse(args)
+
+	selected := []lintRule{}
+	for _, name := range strings.Split(*rulesFlag, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, rule := range lintRules {
+			if rule.name == name {
+				selected = append(selected, rule)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("Unknown lint rule '%s', expected one of: %s", name, strings.Join(ruleNames(), ", "))
+		}
+	}
+
+	if *configPath == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			*configPath = defaultConfigPath
+		}
+	}
+	filter, err := configTeamFilter(*configPath)
+	if err != nil {
+		return err
+	}
+
+	in := &lintInput{org: *org, filter: filter}
+	findings := []Finding{}
+
+	for _, rule := range selected {
+		progress.Printf("running lint rule '%s'\n", rule.name)
+		ruleFindings, err := rule.check(ctx, in)
+		if err != nil {
+			return fmt.Errorf("Error running lint rule '%s': %w", rule.name, err)
+		}
+		findings = append(findings, ruleFindings...)
+	}
+
+	if *reportFlag != "" {
+		err := writeCodeownersReport(ctx, *reportFlag, in)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *loadReportFlag != "" {
+		err := writeCodeownerLoadReport(ctx, *loadReportFlag, in)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *sarifFlag != "" {
+		err := writeSARIF(*sarifFlag, selected, findings)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+
+	if len(findings) > 0 {
+		log.Printf("%d lint findings\n", len(findings))
+		os.Exit(1)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCodeownersTeamsUsesTheConfigFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "org-vis.yaml")
+	err := os.WriteFile(path, []byte(`types:
+  - prefix: guild-
+    key: guild
+    label: Guild
+filters:
+  exclude: [team-legacy]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := configTeamFilter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams := []Team{
+		{Name: "guild-frontend", Slug: "guild-frontend"},
+		{Name: "team-legacy", Slug: "team-legacy"},
+		{Name: "team-rocket", Slug: "team-rocket"},
+	}
+	in := &lintInput{
+		org:    "org",
+		filter: filter,
+		teams:  teams,
+		repos: []lintRepository{{
+			Repository:     Repository{Name: "app"},
+			CodeownersPath: ".github/CODEOWNERS",
+			CodeownersRules: []CodeownersRule{
+				{Line: 1, Owners: []string{"@org/guild-frontend", "@org/team-legacy", "@org/team-rocket"}},
+			},
+		}},
+		teamRepos: map[string]map[string]Permissions{},
+	}
+	for _, team := range teams {
+		in.teamRepos[team.Slug] = map[string]Permissions{"app": {Push: true}}
+	}
+
+	findings, err := checkCodeownersTeams(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(findings) != 1 {
+		t.Fatalf("expected one finding for team-legacy, got %v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "team-legacy") || !strings.Contains(findings[0].Message, "guild-") {
+		t.Errorf("expected the finding to name team-legacy and the configured prefixes, got '%s'", findings[0].Message)
+	}
+}
+
+func TestConfigTeamFilterDefaults(t *testing.T) {
+	filter, err := configTeamFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter.relevant("team-rocket") || filter.relevant("guild-frontend") || filter.relevant("team-engineers") {
+		t.Errorf("expected the default filter, got %+v", filter)
+	}
+}
@@ -1,108 +1,283 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+	"golang.org/x/sync/errgroup"
 )
 
+const defaultOrg = "giantswarm"
+
+// orgFromEnv is the default of every --org flag, ORG_VIS_ORG or defaultOrg.
+func orgFromEnv() string {
+	if org := os.Getenv("ORG_VIS_ORG"); org != "" {
+		return org
+	}
+	return defaultOrg
+}
+
+// exitChanged is the exit status used with --exit-code when outputs changed.
+const exitChanged = 2
+
+// exitAlert is the exit status when an alert rule of the config fired.
+const exitAlert = 3
+
 type Team struct {
 	Name       string   `json:"name"`
 	Slug       string   `json:"slug"`
 	MembersURL string   `json:"members_url"`
+	Privacy    string   `json:"privacy"`
 	Members    []string `json:"members"`
+	Parent     *TeamRef `json:"parent"`
+	Repos      []string `json:"repos,omitempty"`
+	Projects   []string `json:"projects,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	OnCall     []string `json:"on_call,omitempty"`
+
+	// Maintainers is only fetched when needed, for the membership API and the
+	// membership matrix.
+	Maintainers []string `json:"maintainers,omitempty"`
+
+	DiscussionPosts *int      `json:"discussion_posts,omitempty"`
+	IdPGroups       *[]string `json:"idp_groups,omitempty"`
+	Health          *float64  `json:"health,omitempty"`
+	ReviewLatency   *float64  `json:"review_latency_hours,omitempty"`
+}
+
+type TeamRef struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type Member struct {
 	Name string `json:"login"`
 }
 
-type Graph []Node
-
-type Node struct {
-	Name        string   `json:"name"`
-	Memberships []string `json:"memberships"`
+func fetchJSON(ctx context.Context, url string) ([]byte, error) {
+	return fetchCached(ctx, url, github.Get)
 }
 
-func fetchJSON(url string) ([]byte, error) {
-	ghToken := os.Getenv("GITHUB_TOKEN")
+// fetchAllJSON is fetchJSON for list endpoints, following all pages.
+func fetchAllJSON(ctx context.Context, url string) ([]byte, error) {
+	return fetchCached(ctx, url, github.GetAll)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Error constructing request for url '%s': %v", url, err)
+func fetchCached(ctx context.Context, url string, get func(context.Context, string) ([]byte, error)) ([]byte, error) {
+	if cache != nil && ctx.Value(noCacheKey{}) == nil {
+		if body, ok := cache.get(url); ok {
+			return body, nil
+		}
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("Authorization", "token "+ghToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	body, err := get(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("Error fetching url '%s': %v", url, err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Error reading response bytes: %v", err)
+	if cache != nil {
+		err = cache.put(url, body)
+		if err != nil {
+			log.Printf("Error caching response for url '%s': %v\n", url, err)
+		}
 	}
 
-	return bodyBytes, nil
+	return body, nil
 }
 
-func fetchTeams() ([]Team, error) {
-	log.Println("fetching teams")
-	teamBytes, err := fetchJSON("https://api.github.com/orgs/giantswarm/teams?per_page=100")
+func fetchAllTeams(ctx context.Context, org string) ([]Team, error) {
+	progress.Println("fetching teams")
+	teamBytes, err := fetchAllJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams?per_page=100", github.APIURL, org))
+	var notFound *github.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("Error fetching teams: unknown organization '%s', or the token isn't a member of it: %w", org, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("Error fetching teams: %v", err)
+		return nil, fmt.Errorf("Error fetching teams: %w", err)
 	}
 
 	var teams []Team
 
 	err = json.Unmarshal(teamBytes, &teams)
 	if err != nil {
-		return nil, fmt.Errorf("Error parsing teams: %v", err)
+		return nil, fmt.Errorf("Error parsing teams: %w", err)
 	}
 
-	relevantTeams := []Team{}
+	return teams, nil
+}
+
+// fetchConcurrency is how many teams are fetched at the same time.
+var fetchConcurrency = 4
+
+// forEachTeam calls fn for the index of every team with at most
+// fetchConcurrency calls running at once. fn must only modify its own team.
+// The first error cancels the context of the remaining calls.
+func forEachTeam(ctx context.Context, teams []Team, fn func(ctx context.Context, i int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(fetchConcurrency)
+
+	for i := range teams {
+		i := i
+		g.Go(func() error {
+			return fn(ctx, i)
+		})
+	}
+
+	return g.Wait()
+}
+
+func fetchTeams(ctx context.Context, org string, filter teamFilter) ([]Team, error) {
+	teams, err := fetchAllTeams(ctx, org)
+	if err != nil {
+		return nil, err
+	}
 
+	relevantTeams := []Team{}
 	for _, team := range teams {
-		if teamRelevant(team.Name) {
-			members, err := fetchTeamMembers(team.Slug)
-			if err != nil {
-				return nil, fmt.Errorf("Error fetching team members for slug %s: %v", team.Slug, err)
-			}
-			team.Members = members
+		team = filter.names.normalize(team)
+		if filter.includes(team) {
 			relevantTeams = append(relevantTeams, team)
 		}
 	}
 
+	err = forEachTeam(ctx, relevantTeams, func(ctx context.Context, i int) error {
+		members, err := fetchTeamMembers(ctx, org, relevantTeams[i].Slug, filter.role())
+		if err != nil {
+			return fmt.Errorf("Error fetching team members for slug %s: %w", relevantTeams[i].Slug, err)
+		}
+		relevantTeams[i].Members = members
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return relevantTeams, nil
 }
 
-func teamRelevant(teamName string) bool {
+type teamFilter struct {
+	Prefixes        []string `yaml:"prefixes"`
+	ExcludeSuffixes []string `yaml:"exclude_suffixes"`
+	Exclude         []string `yaml:"exclude"`
+	// Privacy is "closed" or "secret" to only include teams with that
+	// privacy level, or empty or "all" to include both.
+	Privacy string `yaml:"privacy"`
+	// Role restricts team members to "maintainer" or "member" (everyone
+	// but the maintainers). Empty or "all" includes everyone.
+	Role string `yaml:"role"`
+	// Allowlist and Denylist are files of team slugs. Allowed teams are
+	// included regardless of their name, denied teams are always excluded.
+	Allowlist string `yaml:"allowlist"`
+	Denylist  string `yaml:"denylist"`
+
+	allow []string
+	deny  []string
+	// names normalizes team names before the filter and everything else
+	// sees them.
+	names nameRules
+}
+
+var defaultTeamFilter = teamFilter{
+	Prefixes:        []string{"sig-", "team-", "wg-"},
+	ExcludeSuffixes: []string{"-engineers"},
+}
+
+func (f teamFilter) relevant(teamName string) bool {
 	lowerName := strings.ToLower(teamName)
-	return ((strings.HasPrefix(lowerName, "sig-") ||
-		strings.HasPrefix(lowerName, "team-") ||
-		strings.HasPrefix(lowerName, "wg-")) &&
-		!strings.HasSuffix(lowerName, "-engineers"))
+
+	for _, name := range f.Exclude {
+		if strings.ToLower(name) == lowerName {
+			return false
+		}
+	}
+	for _, suffix := range f.ExcludeSuffixes {
+		if strings.HasSuffix(lowerName, strings.ToLower(suffix)) {
+			return false
+		}
+	}
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(lowerName, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f teamFilter) includes(team Team) bool {
+	slug := strings.ToLower(team.Slug)
+	if contains(f.deny, slug) {
+		return false
+	}
+	if f.Privacy != "" && f.Privacy != privacyAll && team.Privacy != f.Privacy {
+		return false
+	}
+	return contains(f.allow, slug) || f.relevant(team.Name)
+}
+
+const (
+	privacyAll    = "all"
+	privacyClosed = "closed"
+	privacySecret = "secret"
+)
+
+const (
+	roleAll        = "all"
+	roleMaintainer = "maintainer"
+	roleMember     = "member"
+)
+
+func (f teamFilter) role() string {
+	if f.Role == "" {
+		return roleAll
+	}
+	return f.Role
+}
+
+func roleFlag(role *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case roleAll, roleMaintainer, roleMember:
+			*role = value
+			return nil
+		}
+		return fmt.Errorf("expected one of %s, %s or %s, got '%s'", roleMaintainer, roleMember, roleAll, value)
+	}
 }
 
-func fetchTeamMembers(slug string) ([]string, error) {
-	log.Printf("fetching team members for '%s'\n", slug)
-	membersBytes, err := fetchJSON(fmt.Sprintf("https://api.github.com/orgs/giantswarm/teams/%s/members?per_page=100", slug))
+func privacyFlag(privacy *string) func(string) error {
+	return func(value string) error {
+		switch value {
+		case privacyAll, privacyClosed, privacySecret:
+			*privacy = value
+			return nil
+		}
+		return fmt.Errorf("expected one of %s, %s or %s, got '%s'", privacyClosed, privacySecret, privacyAll, value)
+	}
+}
+
+func fetchTeamMembers(ctx context.Context, org, slug, role string) ([]string, error) {
+	progress.Printf("fetching team members for '%s'\n", slug)
+	membersBytes, err := fetchAllJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams/%s/members?role=%s&per_page=100", github.APIURL, org, slug, role))
 	if err != nil {
-		return nil, fmt.Errorf("Error fetching members for slug %s: %v", slug, err)
+		return nil, fmt.Errorf("Error fetching members for slug %s: %w", slug, err)
 	}
 
 	var membersResponse []Member
 
 	err = json.Unmarshal(membersBytes, &membersResponse)
 	if err != nil {
-		return nil, fmt.Errorf("Error parsing members for slug %s: %v", slug, err)
+		return nil, fmt.Errorf("Error parsing members for slug %s: %w", slug, err)
 	}
 
 	members := []string{}
@@ -114,22 +289,6 @@ func fetchTeamMembers(slug string) ([]string, error) {
 	return members, nil
 }
 
-func graphTeamName(name string) (string, string, error) {
-	var typeStr string
-
-	if strings.HasPrefix(name, "sig-") {
-		typeStr = "sig"
-	} else if strings.HasPrefix(name, "wg-") {
-		typeStr = "wg"
-	} else if strings.HasPrefix(name, "team-") {
-		typeStr = "team"
-	} else {
-		return "", "", fmt.Errorf("Unknown team name prefix for team '%s'", name)
-	}
-
-	return fmt.Sprintf("giantswarm.%s.%s", typeStr, strings.ReplaceAll(name, " ", "")), typeStr, nil
-}
-
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -139,65 +298,198 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func toGraph(teams []Team) (Graph, error) {
-	g := Graph{}
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"compare":     runCompare,
+	"config":      runConfig,
+	"diff":        runDiff,
+	"keygen":      runKeygen,
+	"lint":        runLint,
+	"path":        runPath,
+	"prune":       runPrune,
+	"self-update": runSelfUpdate,
+	"verify":      runVerify,
+	"warm":        runWarm,
+	"who":         runWho,
+}
 
-	for _, teamA := range teams {
-		teamNameA, typeA, err := graphTeamName(teamA.Name)
-		if err != nil {
-			return g, err
-		}
+func main() {
+	// SIGINT and SIGTERM cancel requests in flight instead of killing the
+	// process halfway through writing the outputs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		memberships := []string{}
+	// Subcommands have no --github-api-url, they only use GITHUB_API_URL.
+	github.APIURL = apiURLFromEnv()
 
-		for _, teamB := range teams {
-			teamNameB, _, err := graphTeamName(teamB.Name)
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			err := command(ctx, os.Args[2:])
 			if err != nil {
-				return g, err
-			}
-			for _, memberB := range teamB.Members {
-				if teamNameA != teamNameB && typeA == "team" && !contains(memberships, teamNameB) && contains(teamA.Members, memberB) {
-					memberships = append(memberships, teamNameB)
-				}
+				log.Printf("Error running %s: %v\n", os.Args[1], err)
+				os.Exit(1)
 			}
+			return
 		}
-		g = append(g, Node{Name: teamNameA, Memberships: memberships})
 	}
 
-	return g, nil
-}
+	base, err := parseOptions(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		exitOptionsError(base != nil && base.summaryJSON, err)
+	}
 
-func main() {
-	teams, err := fetchTeams()
+	if base.quiet {
+		progress.SetOutput(io.Discard)
+	}
+	err = configureHTTP(base.http)
 	if err != nil {
-		log.Printf("Error reading response bytes: %v\n", err)
-		return
+		exitOptionsError(base.summaryJSON, err)
 	}
+	github.APIURL = base.apiURL
+	github.MaxAttempts = base.maxAttempts
+	github.RequestTimeout = base.requestTimeout
+	fetchConcurrency = base.concurrency
+	github.OnThrottle = logThrottle
 
-	graph, err := toGraph(teams)
+	opts, err := resolveOptions(base)
 	if err != nil {
-		log.Printf("Error generating graph: %v\n", err)
+		exitOptionsError(base.summaryJSON, err)
+	}
+
+	if opts.source == "" && github.Anonymous() {
+		log.Printf("neither GITHUB_TOKEN nor GITHUB_APP_ID is set, sending anonymous requests: GitHub allows 60 of them per hour and they only see public data, so secret teams and concealed members are missing\n")
+	}
+
+	if opts.cacheTTL > 0 {
+		cache = &responseCache{dir: opts.cacheDir, ttl: opts.cacheTTL}
+	}
+	if opts.etagCache {
+		github.ResponseCache = &etagCache{dir: opts.cacheDir}
+	}
+
+	if opts.serve != "" {
+		err = serve(ctx, base, opts)
+		if err != nil {
+			log.Printf("Error serving: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	jsonBytes, err := json.Marshal(graph)
-	if err != nil {
-		log.Printf("Error marshaling teams into json: %v\n", err)
+	if opts.watch {
+		err = watch(ctx, base, opts, func(ctx context.Context, teams []Team, opts *options) error {
+			_, err := writeOutputs(ctx, teams, opts)
+			return err
+		})
+		if err != nil {
+			log.Printf("Error watching organization: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	var indentedBytes bytes.Buffer
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	summary := newRunSummary()
+	summary.ExitCode = runOnce(ctx, opts, summary)
+	closeSnapshotStore(opts)
+	logRateLimits()
+	if opts.summaryJSON {
+		summary.print()
+	}
+	if summary.ExitCode != 0 {
+		os.Exit(summary.ExitCode)
+	}
+}
+
+// exitOptionsError logs err of parsing or resolving the options and exits
+// with status 1, after printing the run summary with --summary-json.
+func exitOptionsError(summaryJSON bool, err error) {
+	log.Printf("%v\n", err)
+	if summaryJSON {
+		summary := newRunSummary()
+		summary.ExitCode = 1
+		summary.Error = err.Error()
+		summary.print()
+	}
+	os.Exit(1)
+}
+
+// runOnce collects the teams once and writes or checks the outputs. It
+// returns the exit status.
+func runOnce(ctx context.Context, opts *options, summary *runSummary) int {
+	summary.Outputs = len(opts.formats)
 
-	err = json.Indent(&indentedBytes, jsonBytes, "", "  ")
+	teams, err := collectTeams(ctx, opts)
 	if err != nil {
-		log.Printf("Error indenting json: %v\n", err)
-		return
+		log.Printf("%v\n", err)
+		summary.Error = err.Error()
+		return 1
 	}
+	summary.addTeams(teams)
 
-	log.Println("writing data to assets/org-vis/teams-graph.json")
-	err = os.WriteFile("assets/org-vis/teams-graph.json", indentedBytes.Bytes(), 0644)
+	if opts.check {
+		stale, err := staleOutputs(ctx, teams, opts)
+		if err != nil {
+			log.Printf("%v\n", err)
+			summary.Error = err.Error()
+			return 1
+		}
+		summary.StaleOutputs = stale
+		for _, path := range stale {
+			log.Printf("%s is stale\n", path)
+		}
+		if len(stale) > 0 {
+			return 1
+		}
+		progress.Println("all outputs are up to date")
+		return 0
+	}
+
+	var previous *Snapshot
+	if opts.alerts != nil && len(opts.alerts.Rules) > 0 {
+		if opts.snapshotDir == "" {
+			log.Printf("Alert rules need --snapshot-dir to compare against the previous run\n")
+			summary.Error = "alert rules need --snapshot-dir"
+			return 1
+		}
+		var err error
+		previous, err = latestSnapshot(ctx, opts.snapshots)
+		if err != nil {
+			log.Printf("%v\n", err)
+			summary.Error = err.Error()
+			return 1
+		}
+	}
+
+	changed, err := writeOutputs(ctx, teams, opts)
 	if err != nil {
-		log.Printf("Error writing yaml file: %v", err)
-		return
+		log.Printf("%v\n", err)
+		summary.Error = err.Error()
+		return 1
+	}
+
+	summary.ChangedOutputs = changed
+	summary.Changed = len(changed) > 0
+
+	progress.Printf("changed: %t\n", summary.Changed)
+
+	if previous != nil {
+		alerts := opts.alerts.check(previous.Teams, teams)
+		summary.Alerts = alerts
+		err = opts.alerts.notify(ctx, opts.org, alerts)
+		if err != nil {
+			log.Printf("%v\n", err)
+		}
+		if len(alerts) > 0 {
+			return exitAlert
+		}
+	}
+	if summary.Changed && opts.exitCode {
+		return exitChanged
 	}
+	return 0
 }
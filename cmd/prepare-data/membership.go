@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+)
+
+const membershipMatrixFormat = "membership-csv"
+
+// needsMaintainers reports whether team maintainers have to be fetched in
+// addition to the members.
+func (o *options) needsMaintainers() bool {
+	if o.serve != "" {
+		return true
+	}
+	for _, f := range o.formats {
+		switch f.name {
+		case membershipMatrixFormat, cypherFormat, turtleFormat:
+			return true
+		}
+	}
+	return false
+}
+
+// encodeMembershipCSV writes one row per member and one column per team,
+// with the member's role in the team or an empty cell.
+func encodeMembershipCSV(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.privacyMode {
+		return nil, fmt.Errorf("The %s format lists members and can't be used in privacy mode", membershipMatrixFormat)
+	}
+
+	logins := []string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if !contains(logins, member) {
+				logins = append(logins, member)
+			}
+		}
+	}
+	sort.Strings(logins)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"login"}
+	for _, team := range teams {
+		header = append(header, team.Name)
+	}
+	err := w.Write(header)
+	if err != nil {
+		return nil, fmt.Errorf("Error writing membership csv header: %w", err)
+	}
+
+	for _, login := range logins {
+		record := []string{login}
+		for _, team := range teams {
+			role := ""
+			if contains(team.Members, login) {
+				role = memberRole(team, login)
+			}
+			record = append(record, role)
+		}
+		err = w.Write(record)
+		if err != nil {
+			return nil, fmt.Errorf("Error writing membership csv row: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("Error writing membership csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
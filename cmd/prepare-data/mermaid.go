@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// mermaidNode returns the definition of the node with the given id, shaped
+// by what the node is: rectangles for teams, stadiums for projects and
+// cylinders for repositories.
+func mermaidNode(id string, n Node) string {
+	label := mermaidLabel(n.Name)
+	switch {
+	case n.Type == "repo":
+		return fmt.Sprintf("%s[(\"%s\")]", id, label)
+	case n.MemberCount == nil:
+		return fmt.Sprintf("%s([\"%s\"])", id, label)
+	}
+	return fmt.Sprintf("%s[\"%s\"]", id, label)
+}
+
+// encodeMermaid writes the graph as a Mermaid flowchart, which GitHub
+// markdown, wikis and PR descriptions render natively. Overlap edges are
+// labeled with the number of shared members.
+func encodeMermaid(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("flowchart LR\n")
+
+	// Node names contain slashes and spaces, so nodes get generated ids.
+	ids := map[string]string{}
+	for i, n := range graph.Nodes() {
+		ids[n.Name] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&buf, "  %s\n", mermaidNode(ids[n.Name], n))
+	}
+
+	for _, e := range graph.Edges() {
+		link := "---"
+		if e.Directed {
+			link = "-->"
+		}
+		if e.Kind == edgeOverlap {
+			link = fmt.Sprintf("%s|%d|", link, e.Weight)
+		}
+		fmt.Fprintf(&buf, "  %s %s %s\n", ids[e.Source], link, ids[e.Target])
+	}
+
+	return buf.Bytes(), nil
+}
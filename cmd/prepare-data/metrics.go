@@ -0,0 +1,49 @@
+package main
+
+import "context"
+
+// TeamMetrics are the numbers of one team in the metrics format. Every
+// value is a number, so json_exporter can turn each into a gauge labeled
+// with team and type, e.g. with
+//
+//	metrics:
+//	- name: org_vis_team
+//	  type: object
+//	  path: '{.teams[*]}'
+//	  labels: {team: '{.team}', type: '{.type}'}
+//	  values: {size: '{.size}', overlap_degree: '{.overlap_degree}'}
+type TeamMetrics struct {
+	Team string `json:"team"`
+	Type string `json:"type"`
+	Size int    `json:"size"`
+	// OverlapDegree is the number of other teams sharing at least
+	// --min-shared-members members with the team.
+	OverlapDegree int `json:"overlap_degree"`
+}
+
+type Metrics struct {
+	Org   string        `json:"org"`
+	Teams []TeamMetrics `json:"teams"`
+}
+
+func encodeMetrics(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	minShared := opts.minSharedMembers
+	if minShared < 1 {
+		minShared = 1
+	}
+	overlap := overlapMatrix(teams)
+
+	metrics := Metrics{Org: opts.org, Teams: []TeamMetrics{}}
+	for i, team := range teams {
+		teamType, _ := opts.taxonomy.typeOf(team.Name)
+		degree := 0
+		for _, shared := range overlap[i] {
+			if shared >= minShared {
+				degree++
+			}
+		}
+		metrics.Teams = append(metrics.Teams, TeamMetrics{Team: team.Name, Type: teamType.Key, Size: len(team.Members), OverlapDegree: degree})
+	}
+
+	return marshalIndented(metrics)
+}
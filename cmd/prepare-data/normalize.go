@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// nameRule rewrites team names before their type is detected, so teams
+// named by older conventions end up in the same place of the graph as the
+// current ones. Exactly one of StripSuffix, MapPrefix or TitleCase is set.
+type nameRule struct {
+	StripSuffix string         `yaml:"strip_suffix,omitempty"`
+	MapPrefix   *prefixMapping `yaml:"map_prefix,omitempty"`
+	// TitleCase capitalizes the first letter of every word of the name and
+	// lowercases the rest. Words are separated by spaces, dashes and
+	// underscores, which are kept.
+	TitleCase bool `yaml:"title_case,omitempty"`
+}
+
+type prefixMapping struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// nameRules are applied in order, each to the result of the previous one.
+type nameRules []nameRule
+
+func (r nameRules) validate() error {
+	for i, rule := range r {
+		set := 0
+		if rule.StripSuffix != "" {
+			set++
+		}
+		if rule.MapPrefix != nil {
+			set++
+			if rule.MapPrefix.From == "" {
+				return fmt.Errorf("Rule %d maps an empty prefix", i+1)
+			}
+		}
+		if rule.TitleCase {
+			set++
+		}
+		if set != 1 {
+			return fmt.Errorf("Rule %d must set exactly one of strip_suffix, map_prefix or title_case", i+1)
+		}
+	}
+	return nil
+}
+
+func titleCase(name string) string {
+	result := []rune{}
+	wordStart := true
+	for _, r := range name {
+		if wordStart {
+			result = append(result, unicode.ToUpper(r))
+		} else {
+			result = append(result, unicode.ToLower(r))
+		}
+		wordStart = r == ' ' || r == '-' || r == '_'
+	}
+	return string(result)
+}
+
+// apply returns the normalized name. Prefixes and suffixes match regardless
+// of case, like team type prefixes do.
+func (r nameRules) apply(name string) string {
+	for _, rule := range r {
+		switch {
+		case rule.StripSuffix != "":
+			if strings.HasSuffix(strings.ToLower(name), strings.ToLower(rule.StripSuffix)) {
+				name = name[:len(name)-len(rule.StripSuffix)]
+			}
+		case rule.MapPrefix != nil:
+			if strings.HasPrefix(strings.ToLower(name), strings.ToLower(rule.MapPrefix.From)) {
+				name = rule.MapPrefix.To + name[len(rule.MapPrefix.From):]
+			}
+		case rule.TitleCase:
+			name = titleCase(name)
+		}
+	}
+	return name
+}
+
+// normalize returns team with its name and the name of its parent
+// normalized. Slugs are left alone, as they are needed for the API.
+func (r nameRules) normalize(team Team) Team {
+	if len(r) == 0 {
+		return team
+	}
+	team.Name = r.apply(team.Name)
+	if team.Parent != nil {
+		parent := *team.Parent
+		parent.Name = r.apply(parent.Name)
+		team.Parent = &parent
+	}
+	return team
+}
+
+// mergeNormalizedTeams merges teams whose names became the same by
+// normalization into the first of them, so the graph has a single node
+// for them.
+func mergeNormalizedTeams(teams []Team) []Team {
+	for {
+		indices := map[string][]int{}
+		duplicate := ""
+		for i, team := range teams {
+			key := strings.ToLower(team.Name)
+			indices[key] = append(indices[key], i)
+			if len(indices[key]) == 2 && duplicate == "" {
+				duplicate = key
+			}
+		}
+		if duplicate == "" {
+			return teams
+		}
+
+		slugs := []string{}
+		for _, i := range indices[duplicate] {
+			slugs = append(slugs, teams[i].Slug)
+		}
+		progress.Printf("merging teams %s, named alike after normalization\n", strings.Join(slugs, ", "))
+		teams = mergeTeamsAt(teams, indices[duplicate], "")
+	}
+}
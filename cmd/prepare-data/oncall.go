@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// onCallSchedule maps team names or slugs to their on-call rotations, given
+// as names or URLs of the paging tool, in the order they should be paged.
+type onCallSchedule map[string][]string
+
+func loadOnCallSchedule(path string) (onCallSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading on-call schedule '%s': %w", path, err)
+	}
+
+	schedule := onCallSchedule{}
+	err = yaml.Unmarshal(data, &schedule)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing on-call schedule '%s': %w", path, err)
+	}
+
+	// Team names and slugs are case-insensitive. Keys differing only in case
+	// are merged in sorted order, so the result doesn't depend on map order.
+	teams := []string{}
+	for team := range schedule {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	normalized := onCallSchedule{}
+	for _, team := range teams {
+		rotations := schedule[team]
+		for _, rotation := range rotations {
+			if strings.TrimSpace(rotation) == "" {
+				return nil, fmt.Errorf("Invalid on-call schedule '%s': empty rotation for team '%s'", path, team)
+			}
+		}
+		key := strings.ToLower(team)
+		normalized[key] = append(normalized[key], rotations...)
+	}
+
+	return normalized, nil
+}
+
+// attach sets the rotations of every team listed by name or slug.
+func (s onCallSchedule) attach(teams []Team) {
+	for i := range teams {
+		rotations := []string{}
+		for _, key := range []string{teams[i].Name, teams[i].Slug} {
+			for _, rotation := range s[strings.ToLower(key)] {
+				if !contains(rotations, rotation) {
+					rotations = append(rotations, rotation)
+				}
+			}
+		}
+		if len(rotations) > 0 {
+			teams[i].OnCall = rotations
+		}
+	}
+}
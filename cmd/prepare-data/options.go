@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+	"github.com/jmespath/go-jmespath"
+)
+
+type options struct {
+	configPath string
+	setFlags   map[string]bool
+
+	org        string
+	apiURL     string
+	api        string
+	source     string
+	whatIfPath string
+	whatIf     *whatIfOverlay
+	formatList string
+	formats    []format
+	output     string
+	// outputPaths are path templates of single formats, from the config.
+	outputPaths map[string]string
+	filter      teamFilter
+	taxonomy    taxonomy
+
+	templatePath string
+
+	alerts *alertConfig
+	extra  *extraGraph
+
+	queryExpression string
+	query           *jmespath.JMESPath
+
+	perms filePermissions
+
+	encryption     encryption
+	provenance     bool
+	signingKeyPath string
+	signingKey     ed25519.PrivateKey
+
+	exitCode bool
+	check    bool
+
+	serve        string
+	refreshToken string
+	watch        bool
+	watchConfig  bool
+	interval     time.Duration
+
+	// snapshotDir is the location of the snapshots, opened as snapshots.
+	snapshotDir       string
+	snapshots         snapshotStore
+	snapshotRetention snapshotRetention
+	since             time.Time
+	until             time.Time
+
+	privacyMode bool
+	// pseudonyms replace the member logins with --pseudonymize.
+	pseudonymize      bool
+	pseudonymSaltPath string
+	pseudonyms        *pseudonyms
+
+	rollupMembers    bool
+	minSharedMembers int
+	edgeDirection    string
+	projects         bool
+
+	// pruneImpliedOverlaps is a transitive reduction of the overlap edges.
+	pruneImpliedOverlaps bool
+	edgeJaccard          bool
+
+	// childOfEdges adds child-of edges next to the hierarchy edges, for
+	// consumers that follow edges from a team to its parent.
+	childOfEdges bool
+
+	// people makes the graph bipartite: members become nodes linked to
+	// their teams, instead of overlap edges between the teams.
+	people bool
+
+	imageSize imageSize
+	pngDPI    int
+
+	// repoRelations are fetched by collectTeams with --repo-topology.
+	repoTopology  bool
+	repoRelations []RepoRelation
+
+	discussions       bool
+	discussionsWindow time.Duration
+
+	teamSync bool
+
+	ownershipHealth bool
+
+	reviewLatency       bool
+	reviewLatencyWindow time.Duration
+
+	labelConvention bool
+	labelMappings   map[string][]string
+
+	cacheDir  string
+	cacheTTL  time.Duration
+	etagCache bool
+
+	http           httpOptions
+	maxAttempts    int
+	requestTimeout time.Duration
+	timeout        time.Duration
+	concurrency    int
+
+	quiet       bool
+	summaryJSON bool
+
+	identityMapPath string
+	identities      identityMap
+	ldifBaseDN      string
+
+	onCallPath string
+	onCall     onCallSchedule
+}
+
+// apiURLFromEnv is the default of --github-api-url, GITHUB_API_URL or the
+// API of github.com.
+func apiURLFromEnv() string {
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	}
+	return github.PublicAPIURL
+}
+
+func apiURLFlag(apiURL *string) func(string) error {
+	return func(value string) error {
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("expected an http or https URL like https://github.example.com/api/v3, got '%s'", value)
+		}
+		*apiURL = strings.TrimSuffix(value, "/")
+		return nil
+	}
+}
+
+func dateFlag(t *time.Time) func(string) error {
+	return func(value string) error {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("expected date in YYYY-MM-DD format: %w", err)
+		}
+		*t = parsed
+		return nil
+	}
+}
+
+// parseOptions registers the flags of the main command on fs and parses
+// args. The result still needs to go through resolveOptions.
+func parseOptions(fs *flag.FlagSet, args []string) (*options, error) {
+	base := &options{apiURL: apiURLFromEnv(), api: apiREST, filter: defaultTeamFilter, taxonomy: defaultTaxonomy, perms: defaultFilePermissions, labelMappings: map[string][]string{}, setFlags: map[string]bool{}, imageSize: imageSize{width: 1200, height: 900}, pngDPI: pngBaseDPI}
+
+	fs.StringVar(&base.configPath, "config", "", "YAML config file with org, filters, interval, formats and output paths, reloaded on SIGHUP in watch mode (default "+defaultConfigPath+" if it exists)")
+	fs.StringVar(&base.org, "org", orgFromEnv(), "GitHub organization to visualize, the default can be set with ORG_VIS_ORG")
+	fs.StringVar(&base.source, "source", "", "read teams from this YAML team definition or terraform show -json output instead of the GitHub API, counting the members of child teams as members of their parents like GitHub does")
+	fs.StringVar(&base.whatIfPath, "what-if", "", "YAML overlay of hypothetical changes (move, add, remove, merge, create) applied to the teams before rendering")
+	fs.Func("github-api-url", "REST API base URL, https://<host>/api/v3 for GitHub Enterprise Server, the default can be set with GITHUB_API_URL (default "+github.PublicAPIURL+")", apiURLFlag(&base.apiURL))
+	fs.Func("api", "GitHub API to fetch teams and members with: rest (one request per team) or graphql (default rest)", apiFlag(&base.api))
+	fs.StringVar(&base.formatList, "format", "graph", "comma-separated list of output formats ("+strings.Join(formatNames(), ", ")+")")
+	fs.Func("file-mode", "octal mode of written files (default 0644)", fileModeFlag(&base.perms.mode))
+	fs.BoolVar(&base.perms.respectUmask, "respect-umask", false, "apply the file mode only to new files, filtered by the umask")
+	fs.Func("file-owner", "user name or id to chown written files to", fileOwnerFlag(&base.perms.uid))
+	fs.Func("file-group", "group name or id to chown written files to", fileGroupFlag(&base.perms.gid))
+	fs.Func("encrypt", "encrypt the output files with age or gpg to every --recipient, appending .age or .gpg to their paths (snapshots stay unencrypted)", encryptFlag(&base.encryption.tool))
+	fs.Func("recipient", "age recipient or gpg key id to encrypt the outputs to, can be repeated", recipientFlag(&base.encryption.recipients))
+	fs.BoolVar(&base.provenance, "provenance", false, "write an in-toto provenance statement next to every output file, as <file>"+provenanceSuffix)
+	fs.StringVar(&base.signingKeyPath, "signing-key", "", "sign every output file, and its provenance, with this ed25519 private key created by keygen, as <file>"+signatureSuffix)
+	fs.BoolVar(&base.exitCode, "exit-code", false, fmt.Sprintf("exit with status %d when any output file changed", exitChanged))
+	fs.BoolVar(&base.check, "check", false, "don't write anything, exit with status 1 if any output on disk is stale")
+	fs.StringVar(&base.serve, "serve", "", "serve the frontend and the selected formats from memory on this address, e.g. :8080")
+	fs.StringVar(&base.refreshToken, "refresh-token", "", "enable POST /api/refresh?team=<slug> in serve mode for requests with this bearer token, the default can be set with ORG_VIS_REFRESH_TOKEN")
+	fs.StringVar(&base.templatePath, "template", "", "Go text/template file rendered by the template format")
+	fs.StringVar(&base.queryExpression, "query", "", "JMESPath expression applied to json outputs before writing, e.g. 'nodes[?member_count > `5`].name'")
+	fs.StringVar(&base.output, "output", "", "output path template, e.g. 'data/{{.Org}}/teams-{{.Format}}-{{.Date}}.{{.Ext}}' (default: the format's path below assets/org-vis), or - for stdout")
+	fs.Func("privacy", "only include teams with this privacy level: closed, secret or all (default all)", privacyFlag(&base.filter.Privacy))
+	fs.StringVar(&base.filter.Allowlist, "allowlist", "", "file of team slugs to include regardless of their name, one per line")
+	fs.StringVar(&base.filter.Denylist, "denylist", "", "file of team slugs to always exclude, one per line")
+	fs.Func("roles", "only count team members with this role: maintainer, member (everyone but maintainers) or all (default all)", roleFlag(&base.filter.Role))
+	fs.BoolVar(&base.watch, "watch", false, "keep running and rewrite the outputs whenever the organization changes")
+	fs.BoolVar(&base.watchConfig, "watch-config", false, "reload the config file as soon as it changes in watch mode")
+	fs.DurationVar(&base.interval, "interval", 10*time.Minute, "how often to poll the organization in watch mode")
+	fs.StringVar(&base.snapshotDir, "snapshot-dir", "", "directory, s3://bucket/prefix or sqlite:file.db to store a snapshot of the fetched teams in and read history from")
+	fs.IntVar(&base.snapshotRetention.KeepLast, "snapshot-keep-last", 0, "prune snapshots after saving one, keeping this many recent ones (pruning is off while both keep flags are 0)")
+	fs.IntVar(&base.snapshotRetention.KeepMonthly, "snapshot-keep-monthly", 0, "prune snapshots after saving one, keeping the newest of each of this many months")
+	fs.BoolVar(&base.pseudonymize, "pseudonymize", false, "replace member logins in the outputs and snapshots by pseudonyms that stay the same across runs")
+	fs.StringVar(&base.pseudonymSaltPath, "pseudonym-salt", "", "file with the secret salt of the pseudonyms, created if missing, don't store it with the outputs (default pseudonym-salt in --cache-dir)")
+	fs.BoolVar(&base.rollupMembers, "rollup-members", false, "count the members --what-if adds to child teams as members of their parent teams, GitHub and --source already count child team members")
+	fs.BoolVar(&base.privacyMode, "privacy-mode", false, "leave member logins out of the outputs, formats listing members are refused")
+	fs.IntVar(&base.minSharedMembers, "min-shared-members", 1, "only link teams in the graph that share at least this many members")
+	fs.BoolVar(&base.pruneImpliedOverlaps, "prune-implied-overlaps", false, "leave out overlap edges between teams that are also connected through teams sharing more members")
+	fs.BoolVar(&base.childOfEdges, "child-of-edges", false, "also link child teams to their parent teams with child-of edges, next to the parent to child hierarchy edges")
+	fs.BoolVar(&base.edgeJaccard, "edge-jaccard", false, "add the Jaccard index of the members of overlapping teams to their edges, next to the number of shared members")
+	fs.BoolVar(&base.people, "people", false, "add team members as nodes linked to their teams, instead of linking teams that share members")
+	fs.Func("image-size", "size of rendered images as WIDTHxHEIGHT in pixels (default 1200x900)", imageSizeFlag(&base.imageSize))
+	fs.Func("png-dpi", "resolution of the png format, the image has --image-size pixels at 96 dpi (default 96)", pngDPIFlag(&base.pngDPI))
+	fs.Func("edge-direction", "direction of membership edges: team-to-group, group-to-team or undirected (default team-to-group)", edgeDirectionFlag(&base.edgeDirection))
+	fs.BoolVar(&base.projects, "projects", false, "fetch organization projects and link them to teams by name or repository")
+	fs.BoolVar(&base.repoTopology, "repo-topology", false, "add the fork, template and mirror relationships between repositories of the organization to the graph")
+	fs.BoolVar(&base.discussions, "discussions", false, "count recent team discussion posts as an engagement attribute")
+	fs.DurationVar(&base.discussionsWindow, "discussions-window", 90*24*time.Hour, "how far back discussion posts are counted")
+	fs.BoolVar(&base.teamSync, "team-sync", false, "fetch identity provider group mappings of teams using team sync")
+	fs.BoolVar(&base.ownershipHealth, "ownership-health", false, "score teams by the fraction of their repositories with CODEOWNERS and branch protection")
+	fs.BoolVar(&base.reviewLatency, "review-latency", false, "measure the median time to first review of pull requests in the repositories of each team")
+	fs.DurationVar(&base.reviewLatencyWindow, "review-latency-window", 30*24*time.Hour, "how far back pull requests are measured")
+	fs.BoolVar(&base.labelConvention, "label-convention", true, "map issue labels like team/phoenix to team-phoenix by naming convention")
+	fs.Func("label-mapping", "map an issue label to a team as label=team, can be repeated", labelMappingFlag(base.labelMappings))
+	fs.Func("since", "only use snapshots taken on or after this date (YYYY-MM-DD)", dateFlag(&base.since))
+	fs.Func("until", "only use snapshots taken before the end of this date (YYYY-MM-DD)", dateFlag(&base.until))
+	fs.StringVar(&base.cacheDir, "cache-dir", defaultCacheDir(), "directory of the GitHub API response cache")
+	fs.DurationVar(&base.cacheTTL, "cache-ttl", 0, "reuse cached GitHub API responses younger than this, 0 disables the cache")
+	fs.BoolVar(&base.etagCache, "etag-cache", false, "store ETags of GitHub API responses in --cache-dir and revalidate them with conditional requests, which don't count against the rate limit")
+	fs.IntVar(&base.concurrency, "concurrency", fetchConcurrency, "how many teams to fetch members of at the same time")
+	fs.StringVar(&base.http.proxy, "proxy", "", "proxy URL for all requests (default HTTPS_PROXY, HTTP_PROXY and NO_PROXY)")
+	fs.StringVar(&base.http.caBundle, "ca-bundle", "", "PEM file of CA certificates to trust in addition to the system ones, e.g. of a TLS intercepting proxy")
+	fs.StringVar(&base.http.clientCert, "client-cert", "", "PEM client certificate for mutual TLS, requires --client-key")
+	fs.StringVar(&base.http.clientKey, "client-key", "", "PEM key of --client-cert")
+	fs.BoolVar(&base.http.debug, "debug-http", false, "log every request with its status, rate limit and timing, without credentials")
+	fs.DurationVar(&base.http.connectTimeout, "connect-timeout", 30*time.Second, "give up connecting and completing the TLS handshake after this long")
+	fs.DurationVar(&base.requestTimeout, "request-timeout", github.RequestTimeout, "give up on a single GitHub API request attempt after this long, 0 disables the timeout")
+	fs.DurationVar(&base.timeout, "timeout", 0, "cancel the run, or a single poll in watch mode, after this long, 0 disables the timeout")
+	fs.IntVar(&base.maxAttempts, "max-attempts", github.MaxAttempts, "how often to send a GitHub API request before giving up on connection errors, timeouts and 5xx responses")
+	fs.BoolVar(&base.quiet, "quiet", false, "don't log progress, only warnings and errors")
+	fs.BoolVar(&base.summaryJSON, "summary-json", false, "print a json summary of the run to stdout when done")
+	fs.StringVar(&base.identityMapPath, "identity-map", "", "YAML file mapping GitHub logins to directory user ids or DNs")
+	fs.StringVar(&base.onCallPath, "on-call", "", "YAML file mapping team names or slugs to their on-call rotation names or URLs")
+	fs.StringVar(&base.ldifBaseDN, "ldif-base-dn", "", "base DN of the groups and people written by the ldif format, e.g. dc=example,dc=com")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if base.concurrency < 1 {
+		return nil, fmt.Errorf("--concurrency must be at least 1, got %d", base.concurrency)
+	}
+	if base.maxAttempts < 1 {
+		return nil, fmt.Errorf("--max-attempts must be at least 1, got %d", base.maxAttempts)
+	}
+	if base.minSharedMembers < 1 {
+		return nil, fmt.Errorf("--min-shared-members must be at least 1, got %d", base.minSharedMembers)
+	}
+
+	// The token isn't the flag default, which would show it in the usage.
+	if base.refreshToken == "" {
+		base.refreshToken = os.Getenv("ORG_VIS_REFRESH_TOKEN")
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		base.setFlags[f.Name] = true
+	})
+
+	if base.configPath == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			base.configPath = defaultConfigPath
+		}
+	}
+
+	return base, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OrgChartNode is the nested datasource format used by orgchart.js.
+type OrgChartNode struct {
+	Name     string          `json:"name"`
+	Title    string          `json:"title"`
+	Children []*OrgChartNode `json:"children,omitempty"`
+}
+
+// toOrgChart builds the formal team hierarchy from the parent team
+// relations. Teams whose parent is not part of the fetched set hang off
+// the organisation root.
+func toOrgChart(teams []Team, org string, types taxonomy) (*OrgChartNode, error) {
+	root := &OrgChartNode{Name: org, Title: "org"}
+	nodes := map[string]*OrgChartNode{}
+
+	for _, team := range teams {
+		_, teamType, err := types.graphTeamName(org, team.Name)
+		if err != nil {
+			return nil, err
+		}
+		nodes[team.Slug] = &OrgChartNode{Name: team.Name, Title: teamType.Label}
+	}
+
+	for _, team := range teams {
+		parent := root
+		if team.Parent != nil {
+			if p, ok := nodes[team.Parent.Slug]; ok {
+				parent = p
+			}
+		}
+		parent.Children = append(parent.Children, nodes[team.Slug])
+	}
+
+	sortOrgChart(root)
+
+	return root, nil
+}
+
+func sortOrgChart(node *OrgChartNode) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for _, child := range node.Children {
+		sortOrgChart(child)
+	}
+}
+
+func encodeOrgChart(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	root, err := toOrgChart(teams, opts.org, opts.taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalIndented(root)
+}
+
+func mermaidLabel(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+func encodeMermaidTree(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	root, err := toOrgChart(teams, opts.org, opts.taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("graph TD\n")
+
+	id := 0
+	var walk func(node *OrgChartNode, nodeID string)
+	walk = func(node *OrgChartNode, nodeID string) {
+		for _, child := range node.Children {
+			id++
+			childID := fmt.Sprintf("n%d", id)
+			fmt.Fprintf(&buf, "  %s[\"%s\"]\n", childID, mermaidLabel(child.Name))
+			fmt.Fprintf(&buf, "  %s --> %s\n", nodeID, childID)
+			walk(child, childID)
+		}
+	}
+
+	fmt.Fprintf(&buf, "  n0[\"%s\"]\n", mermaidLabel(root.Name))
+	walk(root, "n0")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+	"gopkg.in/yaml.v3"
+)
+
+// orgCredential authenticates the requests for one organization, either with
+// the token in the environment variable TokenEnv or as a GitHub App
+// installation. Secrets are only referenced, so the file can be committed.
+type orgCredential struct {
+	TokenEnv string `yaml:"token_env"`
+	App      *struct {
+		ID             string `yaml:"id"`
+		InstallationID string `yaml:"installation_id"`
+		PrivateKeyPath string `yaml:"private_key_path"`
+	} `yaml:"app"`
+}
+
+// orgCredentials maps organizations to their credentials. Organizations
+// that aren't listed use GITHUB_TOKEN or GITHUB_APP_ID.
+type orgCredentials map[string]orgCredential
+
+func loadOrgCredentials(path string) (orgCredentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading org credentials '%s': %w", path, err)
+	}
+
+	credentials := orgCredentials{}
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&credentials)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing org credentials '%s': %w", path, err)
+	}
+
+	// Organization names are case-insensitive.
+	normalized := orgCredentials{}
+	for org, credential := range credentials {
+		if (credential.TokenEnv == "") == (credential.App == nil) {
+			return nil, fmt.Errorf("Credentials of organization '%s' in '%s' must set exactly one of token_env or app", org, path)
+		}
+		if credential.App != nil && (credential.App.ID == "" || credential.App.InstallationID == "" || credential.App.PrivateKeyPath == "") {
+			return nil, fmt.Errorf("The app of organization '%s' in '%s' needs an id, installation_id and private_key_path", org, path)
+		}
+		if _, ok := normalized[strings.ToLower(org)]; ok {
+			return nil, fmt.Errorf("Organization '%s' is listed twice in '%s'", org, path)
+		}
+		normalized[strings.ToLower(org)] = credential
+	}
+
+	return normalized, nil
+}
+
+// context returns ctx with the credentials for org, or ctx itself if org
+// isn't listed.
+func (c orgCredentials) context(ctx context.Context, org string) (context.Context, error) {
+	credential, ok := c[strings.ToLower(org)]
+	if !ok {
+		return ctx, nil
+	}
+
+	if credential.App != nil {
+		key, err := os.ReadFile(credential.App.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading GitHub App private key of organization '%s': %w", org, err)
+		}
+		return github.WithApp(ctx, credential.App.ID, credential.App.InstallationID, key)
+	}
+
+	token := os.Getenv(credential.TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s, the token of organization '%s', isn't set", credential.TokenEnv, org)
+	}
+	return github.WithToken(ctx, token), nil
+}
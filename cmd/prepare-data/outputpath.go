@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// outputPathData is available to output path templates, e.g.
+// "snapshots/teams-graph-{{.Date}}.json" or "{{.Org}}/{{.Name}}".
+type outputPathData struct {
+	Org    string
+	Format string
+	Date   string
+	Time   string
+	// Name is the format's default file name, e.g. "teams-graph.json".
+	Name string
+	// Ext is the extension of the default file name without the dot.
+	Ext string
+}
+
+// stdoutPath as --output writes the only selected format to stdout.
+const stdoutPath = "-"
+
+func outputPath(pathTemplate string, f format, org string, now time.Time) (string, error) {
+	if pathTemplate == "" {
+		pathTemplate = f.output
+	}
+
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing output path template '%s': %w", pathTemplate, err)
+	}
+
+	name := filepath.Base(f.output)
+	data := outputPathData{
+		Org:    org,
+		Format: f.name,
+		Date:   now.UTC().Format("2006-01-02"),
+		Time:   now.UTC().Format("150405"),
+		Name:   name,
+		Ext:    strings.TrimPrefix(filepath.Ext(name), "."),
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("Error rendering output path template '%s': %w", pathTemplate, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+func sharedMembers(a, b Team) []string {
+	shared := []string{}
+	for _, member := range a.Members {
+		if contains(b.Members, member) {
+			shared = append(shared, member)
+		}
+	}
+	return shared
+}
+
+// overlapMatrix returns the number of members each pair of teams has in
+// common. The diagonal is left at zero.
+func overlapMatrix(teams []Team) [][]int {
+	matrix := make([][]int, len(teams))
+	for i := range teams {
+		matrix[i] = make([]int, len(teams))
+	}
+
+	for i := range teams {
+		for j := i + 1; j < len(teams); j++ {
+			n := len(sharedMembers(teams[i], teams[j]))
+			matrix[i][j] = n
+			matrix[j][i] = n
+		}
+	}
+
+	return matrix
+}
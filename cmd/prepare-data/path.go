@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/graphalg"
+)
+
+// PathHop is one team on a path, with the members it shares with the
+// previous team.
+type PathHop struct {
+	Team string   `json:"team"`
+	Via  []string `json:"via,omitempty"`
+}
+
+// memberGraph connects every pair of teams that share a member, unlike
+// toGraph which only links from primary teams.
+func memberGraph(teams []Team) *Graph {
+	g := NewGraph()
+	for _, team := range teams {
+		g.AddNode(Node{Name: team.Name})
+	}
+	for _, teamA := range teams {
+		for _, teamB := range teams {
+			shared := sharedMembers(teamA, teamB)
+			if teamA.Name != teamB.Name && len(shared) > 0 {
+				_ = g.AddEdge(Edge{Source: teamA.Name, Target: teamB.Name, Kind: edgeOverlap, Weight: len(shared), Members: shared})
+			}
+		}
+	}
+	return g
+}
+
+func findTeam(teams []Team, nameOrSlug string) (Team, bool) {
+	for _, team := range teams {
+		if strings.EqualFold(team.Name, nameOrSlug) || team.Slug == nameOrSlug {
+			return team, true
+		}
+	}
+	return Team{}, false
+}
+
+func teamPath(teams []Team, from, to string) ([]PathHop, error) {
+	teamFrom, ok := findTeam(teams, from)
+	if !ok {
+		return nil, fmt.Errorf("Unknown team '%s'", from)
+	}
+	teamTo, ok := findTeam(teams, to)
+	if !ok {
+		return nil, fmt.Errorf("Unknown team '%s'", to)
+	}
+
+	names := graphalg.ShortestPath(memberGraph(teams), teamFrom.Name, teamTo.Name)
+	if names == nil {
+		return nil, nil
+	}
+
+	hops := []PathHop{}
+	for i, name := range names {
+		team, _ := findTeam(teams, name)
+		hop := PathHop{Team: team.Name}
+		if i > 0 {
+			previous, _ := findTeam(teams, names[i-1])
+			hop.Via = sharedMembers(previous, team)
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops, nil
+}
+
+func runPath(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("path", flag.ExitOnError)
+	org := flags.String("org", orgFromEnv(), "organization to look up teams in")
+	allTeams := flags.Bool("all-teams", false, "include teams that don't follow the sig-/team-/wg- naming convention")
+	jsonOutput := flags.Bool("json", false, "print the path as json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s path [flags] <team> <team>\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("Expected two teams, got %d", flags.NArg())
+	}
+
+	var teams []Team
+	var err error
+	if *allTeams {
+		teams, err = fetchAllTeamsWithMembers(ctx, *org)
+	} else {
+		teams, err = fetchTeams(ctx, *org, defaultTeamFilter)
+	}
+	if err != nil {
+		return fmt.Errorf("Error fetching teams of %s: %w", *org, err)
+	}
+
+	hops, err := teamPath(teams, flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		if hops == nil {
+			hops = []PathHop{}
+		}
+		data, err := marshalIndented(hops)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if hops == nil {
+		fmt.Printf("%s and %s are not connected\n", flags.Arg(0), flags.Arg(1))
+		return nil
+	}
+
+	for _, hop := range hops {
+		if len(hop.Via) > 0 {
+			fmt.Printf("  via %s\n", strings.Join(hop.Via, ", "))
+		}
+		fmt.Println(hop.Team)
+	}
+
+	return nil
+}
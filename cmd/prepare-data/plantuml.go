@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+func plantUMLLabel(s string) string {
+	return strings.ReplaceAll(s, `"`, "'")
+}
+
+// encodePlantUML writes the graph as a PlantUML component diagram. Teams
+// are grouped into a package per team type, in the order of the taxonomy
+// and colored like it. Other nodes, like projects and repositories, are
+// left outside the packages.
+func encodePlantUML(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	// Node names contain dots and dashes, so nodes get generated aliases.
+	ids := map[string]string{}
+	byType := map[string][]Node{}
+	others := []Node{}
+	for i, n := range graph.Nodes() {
+		ids[n.Name] = fmt.Sprintf("n%d", i)
+		if _, ok := opts.taxonomy.byKey(n.Type); ok && n.MemberCount != nil {
+			byType[n.Type] = append(byType[n.Type], n)
+		} else {
+			others = append(others, n)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("@startuml\n")
+
+	for _, teamType := range opts.taxonomy {
+		nodes := byType[teamType.Key]
+		if len(nodes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "package \"%s\" %s {\n", plantUMLLabel(teamType.Label), teamType.Color)
+		for _, n := range nodes {
+			fmt.Fprintf(&buf, "  component \"%s\" as %s\n", plantUMLLabel(graphNodeLabel(n.Name)), ids[n.Name])
+		}
+		buf.WriteString("}\n")
+	}
+	for _, n := range others {
+		fmt.Fprintf(&buf, "component \"%s\" as %s\n", plantUMLLabel(n.Name), ids[n.Name])
+	}
+
+	for _, e := range graph.Edges() {
+		link := "--"
+		if e.Directed {
+			link = "-->"
+		}
+		label := ""
+		if e.Kind == edgeOverlap {
+			label = fmt.Sprintf(" : %d", e.Weight)
+		}
+		fmt.Fprintf(&buf, "%s %s %s%s\n", ids[e.Source], link, ids[e.Target], label)
+	}
+
+	buf.WriteString("@enduml\n")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// pngBaseDPI is the resolution --image-size is given in. Higher --png-dpi
+// renders the same picture with more pixels.
+const pngBaseDPI = 96
+
+func pngDPIFlag(dpi *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 48 || n > 1200 {
+			return fmt.Errorf("expected a resolution between 48 and 1200 dpi, got '%s'", value)
+		}
+		*dpi = n
+		return nil
+	}
+}
+
+func parseHexColor(s string) color.RGBA {
+	var c color.RGBA
+	_, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	if err != nil {
+		return color.RGBA{0x99, 0x99, 0x99, 0xff}
+	}
+	c.A = 0xff
+	return c
+}
+
+// pngCanvas draws antialiased shapes in the coordinates of the layout,
+// scaled to the resolution of the image.
+type pngCanvas struct {
+	img   *image.RGBA
+	scale float64
+}
+
+func (c *pngCanvas) fill(path func(r *vector.Rasterizer), fill color.Color) {
+	bounds := c.img.Bounds()
+	r := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	path(r)
+	r.Draw(c.img, bounds, image.NewUniform(fill), image.Point{})
+}
+
+func (c *pngCanvas) polygon(points []point, fill color.Color) {
+	c.fill(func(r *vector.Rasterizer) {
+		r.MoveTo(float32(points[0].X*c.scale), float32(points[0].Y*c.scale))
+		for _, p := range points[1:] {
+			r.LineTo(float32(p.X*c.scale), float32(p.Y*c.scale))
+		}
+		r.ClosePath()
+	}, fill)
+}
+
+func (c *pngCanvas) line(from, to point, width float64, stroke color.Color) {
+	length := math.Hypot(to.X-from.X, to.Y-from.Y)
+	if length == 0 {
+		return
+	}
+	// The normal, half the width long.
+	nx, ny := -(to.Y-from.Y)/length*width/2, (to.X-from.X)/length*width/2
+	c.polygon([]point{
+		{from.X + nx, from.Y + ny},
+		{to.X + nx, to.Y + ny},
+		{to.X - nx, to.Y - ny},
+		{from.X - nx, from.Y - ny},
+	}, stroke)
+}
+
+func (c *pngCanvas) circle(center point, radius float64, fill color.Color) {
+	points := []point{}
+	for i := 0; i < 48; i++ {
+		angle := 2 * math.Pi * float64(i) / 48
+		points = append(points, point{center.X + radius*math.Cos(angle), center.Y + radius*math.Sin(angle)})
+	}
+	c.polygon(points, fill)
+}
+
+// text draws s centered below top. The bitmap font only has one size, so
+// the text is drawn at the base resolution and scaled.
+func (c *pngCanvas) text(s string, top point, fill color.Color) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, s).Ceil()
+	height := face.Metrics().Height.Ceil()
+	if width == 0 {
+		return
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Metrics().Ascent.Ceil())}
+	d.DrawString(s)
+
+	x, y := (top.X-float64(width)/2)*c.scale, top.Y*c.scale
+	target := image.Rect(int(x), int(y), int(x+float64(width)*c.scale), int(y+float64(height)*c.scale))
+	scaled := image.NewAlpha(image.Rect(0, 0, target.Dx(), target.Dy()))
+	draw.BiLinear.Scale(scaled, scaled.Bounds(), mask, mask.Bounds(), draw.Src, nil)
+	draw.DrawMask(c.img, target, image.NewUniform(fill), image.Point{}, scaled, image.Point{}, draw.Over)
+}
+
+// renderPNG draws g like renderSVG, at dpi.
+func renderPNG(g *Graph, layout map[string]point, size imageSize, dpi int, types taxonomy) ([]byte, error) {
+	scale := float64(dpi) / pngBaseDPI
+	img := image.NewRGBA(image.Rect(0, 0, int(float64(size.width)*scale), int(float64(size.height)*scale)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	c := &pngCanvas{img: img, scale: scale}
+
+	edgeColor := parseHexColor("#bbbbbb")
+	arrowColor := parseHexColor("#888888")
+	for _, e := range g.Edges() {
+		from, to := layout[e.Source], layout[e.Target]
+		width := 1 + math.Log(float64(e.Weight)+1)
+		if target, ok := g.Node(e.Target); ok && e.Directed {
+			distance := math.Hypot(to.X-from.X, to.Y-from.Y)
+			if distance > 0 {
+				ux, uy := (to.X-from.X)/distance, (to.Y-from.Y)/distance
+				r := svgNodeRadius(target)
+				tip := point{to.X - ux*r, to.Y - uy*r}
+				base := point{tip.X - ux*8, tip.Y - uy*8}
+				c.line(from, base, width, edgeColor)
+				c.polygon([]point{tip, {base.X - uy*4, base.Y + ux*4}, {base.X + uy*4, base.Y - ux*4}}, arrowColor)
+				continue
+			}
+		}
+		c.line(from, to, width, edgeColor)
+	}
+
+	for _, n := range g.Nodes() {
+		p := layout[n.Name]
+		r := svgNodeRadius(n)
+		c.circle(p, r+1, color.White)
+		c.circle(p, r, parseHexColor(svgNodeColor(n, types)))
+		c.text(graphNodeLabel(n.Name), point{p.X, p.Y + r + 2}, color.Black)
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, fmt.Errorf("Error encoding png: %w", err)
+	}
+
+	return withPNGResolution(buf.Bytes(), dpi), nil
+}
+
+// withPNGResolution adds a pHYs chunk recording dpi right after the IHDR
+// chunk, so slides and documents show the image at its intended size.
+func withPNGResolution(data []byte, dpi int) []byte {
+	// The signature and the IHDR chunk with its 13 bytes of data.
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+
+	pixelsPerMeter := uint32(math.Round(float64(dpi) / 0.0254))
+	chunk := make([]byte, 4+4+9+4)
+	binary.BigEndian.PutUint32(chunk[0:], 9)
+	copy(chunk[4:], "pHYs")
+	binary.BigEndian.PutUint32(chunk[8:], pixelsPerMeter)
+	binary.BigEndian.PutUint32(chunk[12:], pixelsPerMeter)
+	// The unit is the meter.
+	chunk[16] = 1
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	result := append([]byte{}, data[:ihdrEnd]...)
+	result = append(result, chunk...)
+	return append(result, data[ihdrEnd:]...)
+}
+
+// encodePNG renders the graph with the built-in layout, for slides, wikis
+// and chat messages.
+func encodePNG(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	layout := forceLayout(graph, opts.org, opts.imageSize, svgMargin)
+
+	return renderPNG(graph, layout, opts.imageSize, opts.pngDPI, opts.taxonomy)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+type Project struct {
+	Number       int      `json:"number"`
+	Title        string   `json:"title"`
+	URL          string   `json:"url"`
+	Repositories []string `json:"repositories"`
+}
+
+const projectsQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    projectsV2(first: 100, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number
+        title
+        url
+        closed
+        repositories(first: 100) { nodes { name } }
+      }
+    }
+  }
+}`
+
+func fetchProjects(ctx context.Context, org string) ([]Project, error) {
+	progress.Println("fetching projects")
+
+	projects := []Project{}
+	var after interface{}
+
+	for {
+		var data struct {
+			Organization struct {
+				ProjectsV2 struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Number       int    `json:"number"`
+						Title        string `json:"title"`
+						URL          string `json:"url"`
+						Closed       bool   `json:"closed"`
+						Repositories struct {
+							Nodes []struct {
+								Name string `json:"name"`
+							} `json:"nodes"`
+						} `json:"repositories"`
+					} `json:"nodes"`
+				} `json:"projectsV2"`
+			} `json:"organization"`
+		}
+
+		err := github.GraphQL(ctx, projectsQuery, map[string]interface{}{"org": org, "after": after}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching projects: %w", err)
+		}
+
+		for _, node := range data.Organization.ProjectsV2.Nodes {
+			if node.Closed {
+				continue
+			}
+			project := Project{Number: node.Number, Title: node.Title, URL: node.URL, Repositories: []string{}}
+			for _, repo := range node.Repositories.Nodes {
+				project.Repositories = append(project.Repositories, repo.Name)
+			}
+			projects = append(projects, project)
+		}
+
+		pageInfo := data.Organization.ProjectsV2.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	return projects, nil
+}
+
+// projectBelongsToTeam associates a project with a team either by naming
+// convention (the project title mentions the team name, with or without its
+// type prefix, as whole words) or by the project linking one of the team's
+// repositories.
+func projectBelongsToTeam(project Project, team Team) bool {
+	title := words(project.Title)
+	name := words(team.Name)
+
+	if containsWords(title, name) {
+		return true
+	}
+	if len(name) > 1 && containsWords(title, name[1:]) {
+		return true
+	}
+
+	for _, repo := range project.Repositories {
+		if contains(team.Repos, repo) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// words splits s into lower case runs of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords reports whether want appears in s as consecutive words.
+func containsWords(s, want []string) bool {
+	if len(want) == 0 {
+		return false
+	}
+	for i := 0; i+len(want) <= len(s); i++ {
+		matches := true
+		for j, word := range want {
+			if s[i+j] != word {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func attachProjects(ctx context.Context, org string, teams []Team) error {
+	projects, err := fetchProjects(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	for i := range teams {
+		repos, err := fetchTeamRepos(ctx, org, teams[i].Slug)
+		if err != nil {
+			return err
+		}
+		teams[i].Repos = []string{}
+		for _, repo := range repos {
+			teams[i].Repos = append(teams[i].Repos, repo.Name)
+		}
+
+		for _, project := range projects {
+			if projectBelongsToTeam(project, teams[i]) {
+				teams[i].Projects = append(teams[i].Projects, project.Title)
+			}
+		}
+		sort.Strings(teams[i].Projects)
+	}
+
+	return nil
+}
+
+func graphProjectName(org, title string) string {
+	return org + ".project." + strings.NewReplacer(" ", "", ".", "-").Replace(title)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestProjectBelongsToTeam(t *testing.T) {
+	cases := []struct {
+		title   string
+		team    string
+		repos   []string
+		project []string
+		belongs bool
+	}{
+		{title: "team-ui roadmap", team: "team-ui", belongs: true},
+		{title: "Team UI roadmap", team: "team-ui", belongs: true},
+		{title: "UI: next quarter", team: "team-ui", belongs: true},
+		{title: "Build pipeline", team: "team-ui", belongs: false},
+		{title: "Maintainance", team: "wg-ai", belongs: false},
+		{title: "AI platform", team: "wg-ai", belongs: true},
+		{title: "Kubernetes upgrades", team: "sig-kubernetes-upgrades", belongs: true},
+		{title: "Kubernetes", team: "sig-kubernetes-upgrades", belongs: false},
+		{title: "Upgrades", team: "team-upgrade", belongs: false},
+		{title: "Roadmap", team: "team-rocket", repos: []string{"rocket"}, project: []string{"rocket"}, belongs: true},
+		{title: "Roadmap", team: "team-rocket", repos: []string{"rocket"}, project: []string{"launcher"}, belongs: false},
+	}
+	for _, c := range cases {
+		project := Project{Title: c.title, Repositories: c.project}
+		team := Team{Name: c.team, Repos: c.repos}
+		if belongs := projectBelongsToTeam(project, team); belongs != c.belongs {
+			t.Errorf("expected '%s' belonging to %s to be %t, got %t", c.title, c.team, c.belongs, belongs)
+		}
+	}
+}
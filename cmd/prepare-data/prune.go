@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// snapshotRetention decides which snapshots survive pruning. A snapshot is
+// kept if it is one of the KeepLast newest, or the newest of its month in
+// the KeepMonthly months up to now. Zero values keep every snapshot.
+type snapshotRetention struct {
+	KeepLast    int
+	KeepMonthly int
+}
+
+func (r snapshotRetention) enabled() bool {
+	return r.KeepLast > 0 || r.KeepMonthly > 0
+}
+
+// expiredSnapshots returns the snapshots of refs, newest first, that r
+// doesn't keep.
+func (r snapshotRetention) expiredSnapshots(refs []snapshotRef, now time.Time) []snapshotRef {
+	if !r.enabled() {
+		return nil
+	}
+
+	months := map[string]bool{}
+	for i := 0; i < r.KeepMonthly; i++ {
+		months[now.UTC().AddDate(0, -i, 1-now.UTC().Day()).Format("2006-01")] = true
+	}
+
+	expired := []snapshotRef{}
+	for i, ref := range refs {
+		// refs are sorted newest first, so the first one seen of a month is
+		// its newest. Months are counted in UTC, like the current one.
+		month := ref.takenAt.UTC().Format("2006-01")
+		newestOfMonth := months[month]
+		delete(months, month)
+
+		if i < r.KeepLast || newestOfMonth {
+			continue
+		}
+		expired = append(expired, ref)
+	}
+
+	return expired
+}
+
+// pruneSnapshots removes the snapshots in store that r doesn't keep and
+// returns their locations. With dryRun nothing is removed.
+func pruneSnapshots(ctx context.Context, store snapshotStore, r snapshotRetention, now time.Time, dryRun bool) ([]string, error) {
+	refs, err := store.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, ref := range r.expiredSnapshots(refs, now) {
+		if !dryRun {
+			err := store.remove(ctx, ref)
+			if err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, ref.location)
+	}
+
+	return removed, nil
+}
+
+func runPrune(ctx context.Context, args []string) error {
+	r := snapshotRetention{}
+
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	dir := flags.String("snapshot-dir", "", "directory, s3://bucket/prefix or sqlite:file.db the snapshots are stored in")
+	flags.IntVar(&r.KeepLast, "keep-last", 30, "number of most recent snapshots to keep")
+	flags.IntVar(&r.KeepMonthly, "keep-monthly", 12, "number of months to keep the newest snapshot of, counting back from the current month")
+	dryRun := flags.Bool("dry-run", false, "only print the snapshots that would be removed")
+	flags.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("--snapshot-dir is required")
+	}
+	if !r.enabled() {
+		return fmt.Errorf("At least one of --keep-last and --keep-monthly has to be positive")
+	}
+
+	store, err := openSnapshotStore(*dir, defaultFilePermissions)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	removed, err := pruneSnapshots(ctx, store, r, time.Now(), *dryRun)
+	for _, path := range removed {
+		fmt.Println(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		progress.Printf("would remove %d snapshots\n", len(removed))
+	} else {
+		progress.Printf("removed %d snapshots\n", len(removed))
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpiredSnapshots(t *testing.T) {
+	now := time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC)
+	plusTwo := time.FixedZone("UTC+2", 2*60*60)
+
+	cases := map[string]struct {
+		retention snapshotRetention
+		now       time.Time
+		takenAt   []time.Time
+		expired   []int
+	}{
+		"disabled": {
+			retention: snapshotRetention{},
+			takenAt: []time.Time{
+				time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"keep last": {
+			retention: snapshotRetention{KeepLast: 2},
+			takenAt: []time.Time{
+				time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC),
+			},
+			expired: []int{2, 3},
+		},
+		"month boundaries": {
+			retention: snapshotRetention{KeepMonthly: 3},
+			takenAt: []time.Time{
+				time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+				time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC),
+			},
+			expired: []int{2, 4},
+		},
+		"months across a year": {
+			retention: snapshotRetention{KeepMonthly: 2},
+			now:       time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			takenAt: []time.Time{
+				time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+			},
+			expired: []int{2},
+		},
+		"keep last covering the newest of a month": {
+			retention: snapshotRetention{KeepLast: 2, KeepMonthly: 2},
+			takenAt: []time.Time{
+				time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			},
+			expired: []int{3, 4},
+		},
+		"keep last past the kept months": {
+			retention: snapshotRetention{KeepLast: 3, KeepMonthly: 1},
+			takenAt: []time.Time{
+				time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+			expired: []int{3},
+		},
+		"local taken at": {
+			retention: snapshotRetention{KeepMonthly: 2},
+			takenAt: []time.Time{
+				// Still February in UTC.
+				time.Date(2024, 3, 1, 1, 0, 0, 0, plusTwo),
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			},
+			expired: []int{1},
+		},
+		"local now": {
+			retention: snapshotRetention{KeepMonthly: 1},
+			// Still March in UTC.
+			now: time.Date(2024, 4, 1, 1, 0, 0, 0, plusTwo),
+			takenAt: []time.Time{
+				time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC),
+			},
+			expired: []int{1},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			if c.now.IsZero() {
+				c.now = now
+			}
+			refs := []snapshotRef{}
+			for _, takenAt := range c.takenAt {
+				refs = append(refs, snapshotRef{location: takenAt.Format(time.RFC3339), takenAt: takenAt})
+			}
+			expected := []snapshotRef{}
+			for _, i := range c.expired {
+				expected = append(expected, refs[i])
+			}
+
+			expired := c.retention.expiredSnapshots(refs, c.now)
+			if !c.retention.enabled() {
+				if expired != nil {
+					t.Errorf("expected nothing to expire, got %v", expired)
+				}
+				return
+			}
+			if !reflect.DeepEqual(expired, expected) {
+				t.Errorf("expected %v, got %v", expected, expired)
+			}
+		})
+	}
+}
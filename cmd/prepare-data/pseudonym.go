@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const pseudonymSaltFile = "pseudonym-salt"
+
+// pseudonyms replaces logins by an HMAC of the login keyed with a secret
+// salt. The salt is kept in a file, so people have the same pseudonym in
+// every run and snapshots can be compared over time. Anyone with the salt
+// can check which login a pseudonym belongs to, so it must not be stored
+// next to the anonymized data.
+type pseudonyms struct {
+	salt []byte
+}
+
+// loadPseudonyms reads the salt from path, creating a random one if the
+// file doesn't exist yet.
+func loadPseudonyms(path string) (*pseudonyms, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createPseudonyms(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading pseudonym salt '%s': %w", path, err)
+	}
+
+	salt, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(salt) < 16 {
+		return nil, fmt.Errorf("Invalid pseudonym salt '%s': expected at least 16 hex encoded bytes", path)
+	}
+
+	return &pseudonyms{salt: salt}, nil
+}
+
+func createPseudonyms(path string) (*pseudonyms, error) {
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating pseudonym salt: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating directory of pseudonym salt '%s': %w", path, err)
+	}
+	// O_EXCL, so concurrent runs don't replace each other's salt.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return loadPseudonyms(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error creating pseudonym salt '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintln(file, hex.EncodeToString(salt))
+	if err != nil {
+		return nil, fmt.Errorf("Error writing pseudonym salt '%s': %w", path, err)
+	}
+	progress.Printf("created pseudonym salt %s, keep it to get the same pseudonyms in later runs\n", path)
+
+	return &pseudonyms{salt: salt}, nil
+}
+
+// of returns the pseudonym of login. Logins are case-insensitive.
+func (p *pseudonyms) of(login string) string {
+	mac := hmac.New(sha256.New, p.salt)
+	mac.Write([]byte(strings.ToLower(login)))
+	return "person-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// apply replaces the members and maintainers of teams by their pseudonyms.
+func (p *pseudonyms) apply(teams []Team) {
+	for i := range teams {
+		teams[i].Members = p.ofAll(teams[i].Members)
+		if teams[i].Maintainers != nil {
+			teams[i].Maintainers = p.ofAll(teams[i].Maintainers)
+		}
+	}
+}
+
+func (p *pseudonyms) ofAll(logins []string) []string {
+	result := make([]string, len(logins))
+	for i, login := range logins {
+		result[i] = p.of(login)
+	}
+	return result
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// applyQuery runs a JMESPath expression against JSON output and returns the
+// indented result.
+func applyQuery(query *jmespath.JMESPath, f format, data []byte) ([]byte, error) {
+	var document interface{}
+
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return nil, fmt.Errorf("--query only works with json formats, %s is not", f.name)
+	}
+
+	result, err := query.Search(document)
+	if err != nil {
+		return nil, fmt.Errorf("Error applying query to %s output: %w", f.name, err)
+	}
+
+	return marshalIndented(result)
+}
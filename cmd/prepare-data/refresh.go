@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+)
+
+// refreshTeam fetches the members of team again, and its maintainers if
+// opts needs them. Everything else about the team is kept until the next
+// poll.
+func refreshTeam(ctx context.Context, opts *options, team Team) (Team, error) {
+	ctx = withoutCache(ctx)
+
+	members, err := fetchTeamMembers(ctx, opts.org, team.Slug, opts.filter.role())
+	if err != nil {
+		return team, err
+	}
+	team.Members = members
+
+	if opts.needsMaintainers() {
+		maintainers, err := fetchTeamMembers(ctx, opts.org, team.Slug, roleMaintainer)
+		if err != nil {
+			return team, fmt.Errorf("Error fetching maintainers for slug %s: %w", team.Slug, err)
+		}
+		team.Maintainers = maintainers
+	}
+
+	if opts.pseudonyms != nil {
+		refreshed := []Team{team}
+		opts.pseudonyms.apply(refreshed)
+		team = refreshed[0]
+	}
+
+	return team, nil
+}
+
+func teamIndex(teams []Team, slug string) int {
+	for i, team := range teams {
+		if team.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// serveRefresh handles POST /api/refresh?team={slug}, which fetches a
+// single team again and publishes the outputs with it, so changes show up
+// without waiting for the next poll. Requests need the refresh token as a
+// bearer token, the endpoint is disabled without one.
+func (s *server) serveRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+	if s.refreshToken == "" {
+		writeAPIError(w, http.StatusNotFound, "refreshing is disabled, start the server with --refresh-token")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.refreshToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="org-vis"`)
+		writeAPIError(w, http.StatusUnauthorized, "missing or invalid refresh token")
+		return
+	}
+
+	slug := r.URL.Query().Get("team")
+	if slug == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing team parameter")
+		return
+	}
+
+	s.mu.RLock()
+	teams := s.teams
+	opts := s.opts
+	s.mu.RUnlock()
+
+	if teams == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "data is not available yet")
+		return
+	}
+	if opts.source != "" {
+		writeAPIError(w, http.StatusBadRequest, "teams are read from %s, there is nothing to refresh", opts.source)
+		return
+	}
+	// Rolled up and hypothetical members depend on the other teams, so a
+	// single team can't be refreshed on its own.
+	if opts.rollupMembers || opts.whatIf != nil {
+		writeAPIError(w, http.StatusBadRequest, "refreshing a single team isn't supported with --rollup-members or --what-if")
+		return
+	}
+
+	i := teamIndex(teams, slug)
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "unknown team %s", slug)
+		return
+	}
+
+	team, err := refreshTeam(r.Context(), opts, teams[i])
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, "%v", err)
+		return
+	}
+
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	// A poll may have published newer teams while fetching.
+	s.mu.RLock()
+	updated := append([]Team{}, s.teams...)
+	s.mu.RUnlock()
+	i = teamIndex(updated, slug)
+	if i < 0 {
+		writeAPIError(w, http.StatusNotFound, "team %s was removed", slug)
+		return
+	}
+	updated[i] = team
+
+	err = s.publishLocked(r.Context(), updated, opts)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	progress.Printf("refreshed team '%s' on request\n", slug)
+
+	if opts.privacyMode {
+		writeAPIJSON(w, http.StatusOK, map[string]int{"members": len(team.Members)})
+		return
+	}
+	result := []APIMember{}
+	for _, member := range team.Members {
+		result = append(result, APIMember{Login: member, Role: memberRole(team, member)})
+	}
+	writeAPIJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// renameSimilarity is the share of members a removed and an added team need
+// to have in common to count as a rename.
+const renameSimilarity = 0.5
+
+// TeamRename is a team that disappeared while a team with mostly the same
+// members appeared. Similarity is the Jaccard index of their members.
+type TeamRename struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Similarity float64 `json:"similarity"`
+}
+
+func memberSimilarity(a, b Team) float64 {
+	shared := len(sharedMembers(a, b))
+	all := len(union(a.Members, b.Members))
+	if all == 0 {
+		return 0
+	}
+	return float64(shared) / float64(all)
+}
+
+// detectRenames pairs teams only in before with teams only in after by
+// member similarity. The most similar pairs are taken first, and every
+// team is part of at most one rename.
+func detectRenames(before, after []Team) []TeamRename {
+	beforeNames := map[string]bool{}
+	for _, team := range before {
+		beforeNames[team.Name] = true
+	}
+	afterNames := map[string]bool{}
+	for _, team := range after {
+		afterNames[team.Name] = true
+	}
+
+	candidates := []TeamRename{}
+	for _, oldTeam := range before {
+		if afterNames[oldTeam.Name] {
+			continue
+		}
+		for _, newTeam := range after {
+			if beforeNames[newTeam.Name] {
+				continue
+			}
+			similarity := memberSimilarity(oldTeam, newTeam)
+			if similarity >= renameSimilarity {
+				candidates = append(candidates, TeamRename{From: oldTeam.Name, To: newTeam.Name, Similarity: math.Round(similarity*100) / 100})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Similarity != candidates[j].Similarity {
+			return candidates[i].Similarity > candidates[j].Similarity
+		}
+		if candidates[i].From != candidates[j].From {
+			return candidates[i].From < candidates[j].From
+		}
+		return candidates[i].To < candidates[j].To
+	})
+
+	renames := []TeamRename{}
+	used := map[string]bool{}
+	for _, candidate := range candidates {
+		if used["from/"+candidate.From] || used["to/"+candidate.To] {
+			continue
+		}
+		used["from/"+candidate.From], used["to/"+candidate.To] = true, true
+		renames = append(renames, candidate)
+	}
+	sort.Slice(renames, func(i, j int) bool {
+		return renames[i].To < renames[j].To
+	})
+
+	return renames
+}
+
+// snapshotRenames returns the renames recorded in after that apply between
+// the two snapshots, or detects them for snapshots without a record.
+func snapshotRenames(before, after Snapshot) []TeamRename {
+	if len(after.Renames) == 0 {
+		return detectRenames(before.Teams, after.Teams)
+	}
+
+	beforeNames := map[string]bool{}
+	for _, team := range before.Teams {
+		beforeNames[team.Name] = true
+	}
+	afterNames := map[string]bool{}
+	for _, team := range after.Teams {
+		afterNames[team.Name] = true
+	}
+
+	renames := []TeamRename{}
+	for _, rename := range after.Renames {
+		if beforeNames[rename.From] && !afterNames[rename.From] && afterNames[rename.To] && !beforeNames[rename.To] {
+			renames = append(renames, rename)
+		}
+	}
+	return renames
+}
+
+// renamedTeams returns teams with the renamed ones carrying their new name,
+// so that comparisons by name follow renames.
+func renamedTeams(teams []Team, renames []TeamRename) []Team {
+	to := map[string]string{}
+	for _, rename := range renames {
+		to[rename.From] = rename.To
+	}
+
+	result := []Team{}
+	for _, team := range teams {
+		if name, ok := to[team.Name]; ok {
+			team.Name = name
+		}
+		result = append(result, team)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectRenames(t *testing.T) {
+	cases := map[string]struct {
+		before  []Team
+		after   []Team
+		renames []TeamRename
+	}{
+		"rename with member churn": {
+			before: []Team{{Name: "team-a", Members: []string{"alice", "bob", "carol", "dave"}}},
+			after:  []Team{{Name: "team-b", Members: []string{"alice", "bob", "carol", "erin"}}},
+			renames: []TeamRename{
+				{From: "team-a", To: "team-b", Similarity: 0.6},
+			},
+		},
+		"too much churn": {
+			before:  []Team{{Name: "team-a", Members: []string{"alice", "bob"}}},
+			after:   []Team{{Name: "team-b", Members: []string{"alice", "carol", "dave"}}},
+			renames: []TeamRename{},
+		},
+		"teams swapping names": {
+			before: []Team{
+				{Name: "team-a", Members: []string{"alice", "bob"}},
+				{Name: "team-b", Members: []string{"carol", "dave"}},
+			},
+			after: []Team{
+				{Name: "team-a", Members: []string{"carol", "dave"}},
+				{Name: "team-b", Members: []string{"alice", "bob"}},
+			},
+			// Both names exist on both sides, so neither team looks
+			// renamed, only their members changed.
+			renames: []TeamRename{},
+		},
+		"ties go by name": {
+			before: []Team{
+				{Name: "team-b", Members: []string{"alice", "bob"}},
+				{Name: "team-a", Members: []string{"alice", "bob"}},
+			},
+			after: []Team{
+				{Name: "team-d", Members: []string{"alice", "bob"}},
+				{Name: "team-c", Members: []string{"alice", "bob"}},
+			},
+			renames: []TeamRename{
+				{From: "team-a", To: "team-c", Similarity: 1},
+				{From: "team-b", To: "team-d", Similarity: 1},
+			},
+		},
+		"tie for one new team": {
+			before: []Team{
+				{Name: "team-b", Members: []string{"alice", "bob"}},
+				{Name: "team-a", Members: []string{"alice", "bob"}},
+			},
+			after: []Team{
+				{Name: "team-c", Members: []string{"alice", "bob"}},
+			},
+			renames: []TeamRename{
+				{From: "team-a", To: "team-c", Similarity: 1},
+			},
+		},
+		"most similar pair first": {
+			before: []Team{
+				{Name: "team-a", Members: []string{"alice", "bob", "carol"}},
+				{Name: "team-b", Members: []string{"alice", "bob", "carol", "dave"}},
+			},
+			after: []Team{
+				{Name: "team-c", Members: []string{"alice", "bob", "carol"}},
+				{Name: "team-d", Members: []string{"alice", "bob", "dave", "erin"}},
+			},
+			renames: []TeamRename{
+				{From: "team-a", To: "team-c", Similarity: 1},
+				{From: "team-b", To: "team-d", Similarity: 0.6},
+			},
+		},
+		"empty teams": {
+			before:  []Team{{Name: "team-a"}},
+			after:   []Team{{Name: "team-b"}},
+			renames: []TeamRename{},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			renames := detectRenames(c.before, c.after)
+			if !reflect.DeepEqual(renames, c.renames) {
+				t.Errorf("expected %v, got %v", c.renames, renames)
+			}
+		})
+	}
+}
+
+func TestSnapshotRenamesKeepsApplyingRecords(t *testing.T) {
+	before := Snapshot{Teams: []Team{
+		{Name: "team-a", Members: []string{"alice"}},
+		{Name: "team-b", Members: []string{"bob"}},
+	}}
+	after := Snapshot{
+		Teams: []Team{
+			{Name: "team-c", Members: []string{"carol"}},
+			{Name: "team-b", Members: []string{"bob"}},
+		},
+		Renames: []TeamRename{
+			{From: "team-a", To: "team-c", Similarity: 0.5},
+			// Recorded against another previous snapshot.
+			{From: "team-x", To: "team-b", Similarity: 0.5},
+		},
+	}
+
+	renames := snapshotRenames(before, after)
+	expected := []TeamRename{{From: "team-a", To: "team-c", Similarity: 0.5}}
+	if !reflect.DeepEqual(renames, expected) {
+		t.Errorf("expected %v, got %v", expected, renames)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+type Repository struct {
+	Name          string      `json:"name"`
+	Archived      bool        `json:"archived"`
+	Fork          bool        `json:"fork"`
+	DefaultBranch string      `json:"default_branch"`
+	Permissions   Permissions `json:"permissions"`
+}
+
+type Permissions struct {
+	Admin bool `json:"admin"`
+	Push  bool `json:"push"`
+	Pull  bool `json:"pull"`
+}
+
+func fetchRepos(ctx context.Context, org string) ([]Repository, error) {
+	progress.Println("fetching repositories")
+	reposBytes, err := fetchAllJSON(ctx, fmt.Sprintf("%s/orgs/%s/repos?per_page=100", github.APIURL, org))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching repositories: %w", err)
+	}
+
+	var repos []Repository
+
+	err = json.Unmarshal(reposBytes, &repos)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing repositories: %w", err)
+	}
+
+	return repos, nil
+}
+
+// fetchTeamRepos returns the repositories a team has access to, with the
+// permissions granted to the team.
+func fetchTeamRepos(ctx context.Context, org, slug string) ([]Repository, error) {
+	progress.Printf("fetching team repositories for '%s'\n", slug)
+	reposBytes, err := fetchAllJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams/%s/repos?per_page=100", github.APIURL, org, slug))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching repositories for slug %s: %w", slug, err)
+	}
+
+	var repos []Repository
+
+	err = json.Unmarshal(reposBytes, &repos)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing repositories for slug %s: %w", slug, err)
+	}
+
+	return repos, nil
+}
+
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// fetchCodeowners returns the CODEOWNERS file GitHub would use for the
+// repository and its path, or an empty path if the repository has none.
+func fetchCodeowners(ctx context.Context, org, repo string) (string, string, error) {
+	for _, path := range codeownersPaths {
+		contentBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/repos/%s/%s/contents/%s", github.APIURL, org, repo, path))
+		var notFound *github.NotFoundError
+		if errors.As(err, &notFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("Error fetching %s for repository %s: %w", path, repo, err)
+		}
+
+		var content struct {
+			Type     string `json:"type"`
+			Encoding string `json:"encoding"`
+			Content  string `json:"content"`
+		}
+
+		err = json.Unmarshal(contentBytes, &content)
+		if err != nil {
+			// Directories are returned as a list, which can't be a CODEOWNERS file.
+			continue
+		}
+		if content.Type != "file" {
+			continue
+		}
+		if content.Encoding != "base64" {
+			return "", "", fmt.Errorf("Unexpected encoding '%s' of %s in repository %s", content.Encoding, path, repo)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+		if err != nil {
+			return "", "", fmt.Errorf("Error decoding %s in repository %s: %w", path, repo, err)
+		}
+
+		return path, string(decoded), nil
+	}
+
+	return "", "", nil
+}
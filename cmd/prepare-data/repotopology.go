@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+const (
+	// edgeFork points from a repository to a fork of it.
+	edgeFork = "fork"
+	// edgeTemplate points from a template repository to a repository
+	// generated from it.
+	edgeTemplate = "template"
+	// edgeMirror points from a repository to a mirror of it.
+	edgeMirror = "mirror"
+)
+
+// RepoRelation is a fork, template or mirror relationship between two
+// repositories of the organization.
+type RepoRelation struct {
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Repo   string `json:"repo"`
+}
+
+const repoTopologyQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    repositories(first: 100, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        mirrorUrl
+        parent { name owner { login } }
+        templateRepository { name owner { login } }
+      }
+    }
+  }
+}`
+
+type graphQLRepoRef struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// inOrg returns the name of the referenced repository if it belongs to org.
+func (r *graphQLRepoRef) inOrg(org string) (string, bool) {
+	if r == nil || !strings.EqualFold(r.Owner.Login, org) {
+		return "", false
+	}
+	return r.Name, true
+}
+
+// mirrorSource returns the repository a mirror URL points to if it belongs
+// to org on github.com.
+func mirrorSource(org, url string) (string, bool) {
+	prefix := strings.ToLower(github.WebURL() + "/" + org + "/")
+	if !strings.HasPrefix(strings.ToLower(url), prefix) {
+		return "", false
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(url[len(prefix):], "/"), ".git")
+	return name, name != "" && !strings.Contains(name, "/")
+}
+
+func fetchRepoTopology(ctx context.Context, org string) ([]RepoRelation, error) {
+	progress.Println("fetching repository topology")
+
+	relations := []RepoRelation{}
+	var after interface{}
+
+	for {
+		var data struct {
+			Organization struct {
+				Repositories struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Name               string          `json:"name"`
+						MirrorURL          string          `json:"mirrorUrl"`
+						Parent             *graphQLRepoRef `json:"parent"`
+						TemplateRepository *graphQLRepoRef `json:"templateRepository"`
+					} `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+
+		err := github.GraphQL(ctx, repoTopologyQuery, map[string]interface{}{"org": org, "after": after}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching repository topology: %w", err)
+		}
+
+		for _, node := range data.Organization.Repositories.Nodes {
+			if parent, ok := node.Parent.inOrg(org); ok {
+				relations = append(relations, RepoRelation{Kind: edgeFork, Source: parent, Repo: node.Name})
+			}
+			if template, ok := node.TemplateRepository.inOrg(org); ok {
+				relations = append(relations, RepoRelation{Kind: edgeTemplate, Source: template, Repo: node.Name})
+			}
+			if source, ok := mirrorSource(org, node.MirrorURL); ok {
+				relations = append(relations, RepoRelation{Kind: edgeMirror, Source: source, Repo: node.Name})
+			}
+		}
+
+		pageInfo := data.Organization.Repositories.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	return relations, nil
+}
+
+func graphRepoName(org, repo string) string {
+	return org + ".repo." + strings.ReplaceAll(repo, ".", "-")
+}
+
+// addRepoTopology adds the repositories of the relations to the graph,
+// linked to each other and to the teams with access to them.
+func addRepoTopology(g *Graph, teams []Team, relations []RepoRelation, opts *options) {
+	repos := []string{}
+	for _, relation := range relations {
+		for _, repo := range []string{relation.Source, relation.Repo} {
+			if !contains(repos, repo) {
+				repos = append(repos, repo)
+				g.AddNode(Node{Name: graphRepoName(opts.org, repo), Type: "repo"})
+			}
+		}
+	}
+
+	for _, relation := range relations {
+		g.AddEdge(Edge{Source: graphRepoName(opts.org, relation.Source), Target: graphRepoName(opts.org, relation.Repo), Kind: relation.Kind, Weight: 1, Directed: true})
+	}
+
+	// Team repositories are only known with --projects.
+	for _, team := range teams {
+		name, _, err := opts.taxonomy.graphTeamName(opts.org, team.Name)
+		if err != nil {
+			continue
+		}
+		for _, repo := range team.Repos {
+			if contains(repos, repo) {
+				g.AddEdge(membershipEdge(opts.edgeDirection, Edge{Source: name, Target: graphRepoName(opts.org, repo), Kind: edgeOwnership, Weight: 1}))
+			}
+		}
+	}
+}
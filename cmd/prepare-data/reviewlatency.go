@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+const pullRequestReviewsQuery = `query($org: String!, $repo: String!, $after: String) {
+  repository(owner: $org, name: $repo) {
+    pullRequests(first: 50, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        createdAt
+        author { login }
+        reviews(first: 20) { nodes { submittedAt author { login } } }
+      }
+    }
+  }
+}`
+
+// fetchReviewLatencies returns the time to the first review of each pull
+// request of repo created since since. Reviews by the author don't count,
+// and pull requests nobody else reviewed are left out.
+func fetchReviewLatencies(ctx context.Context, org, repo string, since time.Time) ([]time.Duration, error) {
+	progress.Printf("fetching pull request reviews for '%s'\n", repo)
+
+	latencies := []time.Duration{}
+	var after interface{}
+
+	for {
+		var data struct {
+			Repository struct {
+				PullRequests struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						CreatedAt time.Time `json:"createdAt"`
+						Author    *struct {
+							Login string `json:"login"`
+						} `json:"author"`
+						Reviews struct {
+							Nodes []struct {
+								SubmittedAt *time.Time `json:"submittedAt"`
+								Author      *struct {
+									Login string `json:"login"`
+								} `json:"author"`
+							} `json:"nodes"`
+						} `json:"reviews"`
+					} `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+
+		err := github.GraphQL(ctx, pullRequestReviewsQuery, map[string]interface{}{"org": org, "repo": repo, "after": after}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching pull request reviews for repository %s: %w", repo, err)
+		}
+
+		pullRequests := data.Repository.PullRequests
+		for _, pr := range pullRequests.Nodes {
+			if pr.CreatedAt.Before(since) {
+				return latencies, nil
+			}
+			for _, review := range pr.Reviews.Nodes {
+				// Pending reviews aren't submitted yet.
+				if review.SubmittedAt == nil {
+					continue
+				}
+				if pr.Author != nil && review.Author != nil && strings.EqualFold(pr.Author.Login, review.Author.Login) {
+					continue
+				}
+				latencies = append(latencies, review.SubmittedAt.Sub(pr.CreatedAt))
+				break
+			}
+		}
+
+		if !pullRequests.PageInfo.HasNextPage {
+			break
+		}
+		after = pullRequests.PageInfo.EndCursor
+	}
+
+	return latencies, nil
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// attachReviewLatency sets the review latency of each team to the median
+// time to first review, in hours, of the pull requests created within the
+// window in the team's unarchived repositories. Teams without reviewed pull
+// requests, or whose repositories can't be read, are left without the
+// attribute.
+func attachReviewLatency(ctx context.Context, org string, teams []Team, window time.Duration) {
+	since := time.Now().Add(-window)
+	// Teams share repositories, so each is only fetched once.
+	byRepo := map[string][]time.Duration{}
+
+	for i := range teams {
+		repos, err := fetchTeamRepos(ctx, org, teams[i].Slug)
+		if err != nil {
+			log.Printf("skipping review latency for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+
+		latencies := []time.Duration{}
+		for _, repo := range repos {
+			if repo.Archived {
+				continue
+			}
+			repoLatencies, ok := byRepo[repo.Name]
+			if !ok {
+				repoLatencies, err = fetchReviewLatencies(ctx, org, repo.Name, since)
+				if err != nil {
+					break
+				}
+				byRepo[repo.Name] = repoLatencies
+			}
+			latencies = append(latencies, repoLatencies...)
+		}
+		if err != nil {
+			log.Printf("skipping review latency for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+		if len(latencies) == 0 {
+			continue
+		}
+
+		// Rounded to tenths of an hour.
+		hours := math.Round(medianDuration(latencies).Hours()*10) / 10
+		teams[i].ReviewLatency = &hours
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+)
+
+// rollupMembers adds the members of all descendant teams to each parent
+// team, mirroring how GitHub cascades team permissions to parents' child
+// teams. Only teams that are part of teams are considered.
+//
+// The member lists of the GitHub API and of team sources already include
+// the members of child teams, filtered ones too, so this only adds the
+// members the --what-if overlay moves into child teams.
+func rollupMembers(teams []Team) {
+	children := map[string][]int{}
+	for i, team := range teams {
+		if team.Parent != nil {
+			children[team.Parent.Slug] = append(children[team.Parent.Slug], i)
+		}
+	}
+
+	direct := make([][]string, len(teams))
+	for i, team := range teams {
+		direct[i] = team.Members
+	}
+
+	var collect func(i int, visited map[int]bool, members map[string]bool)
+	collect = func(i int, visited map[int]bool, members map[string]bool) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, member := range direct[i] {
+			members[member] = true
+		}
+		for _, child := range children[teams[i].Slug] {
+			collect(child, visited, members)
+		}
+	}
+
+	for i := range teams {
+		if len(children[teams[i].Slug]) == 0 {
+			continue
+		}
+
+		members := map[string]bool{}
+		collect(i, map[int]bool{}, members)
+
+		rolledUp := []string{}
+		for member := range members {
+			rolledUp = append(rolledUp, member)
+		}
+		sort.Strings(rolledUp)
+		teams[i].Members = rolledUp
+	}
+}
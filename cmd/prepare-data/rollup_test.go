@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRollupMembers(t *testing.T) {
+	parent := &TeamRef{Name: "team-parent", Slug: "team-parent"}
+	child := &TeamRef{Name: "team-child", Slug: "team-child"}
+
+	cases := map[string]struct {
+		teams    []Team
+		expected map[string][]string
+	}{
+		"overlap": {
+			teams: []Team{
+				{Name: "team-parent", Slug: "team-parent", Members: []string{"alice", "bob"}},
+				{Name: "team-child", Slug: "team-child", Parent: parent, Members: []string{"bob", "carol"}},
+			},
+			expected: map[string][]string{"team-parent": {"alice", "bob", "carol"}, "team-child": {"bob", "carol"}},
+		},
+		"grandchild": {
+			teams: []Team{
+				{Name: "team-parent", Slug: "team-parent", Members: []string{"alice"}},
+				{Name: "team-child", Slug: "team-child", Parent: parent, Members: []string{"bob"}},
+				{Name: "team-grandchild", Slug: "team-grandchild", Parent: child, Members: []string{"carol"}},
+			},
+			expected: map[string][]string{"team-parent": {"alice", "bob", "carol"}, "team-child": {"bob", "carol"}, "team-grandchild": {"carol"}},
+		},
+		"filtered child": {
+			// The child team isn't part of the teams, GitHub already counted
+			// its members for the parent.
+			teams: []Team{
+				{Name: "team-parent", Slug: "team-parent", Members: []string{"alice", "bob"}},
+				{Name: "team-other", Slug: "team-other", Members: []string{"dave"}},
+			},
+			expected: map[string][]string{"team-parent": {"alice", "bob"}, "team-other": {"dave"}},
+		},
+		"cycle": {
+			teams: []Team{
+				{Name: "team-parent", Slug: "team-parent", Parent: child, Members: []string{"alice"}},
+				{Name: "team-child", Slug: "team-child", Parent: parent, Members: []string{"bob"}},
+			},
+			expected: map[string][]string{"team-parent": {"alice", "bob"}, "team-child": {"alice", "bob"}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			rollupMembers(c.teams)
+			for _, team := range c.teams {
+				if !reflect.DeepEqual(team.Members, c.expected[team.Name]) {
+					t.Errorf("expected %s to have %v, got %v", team.Name, c.expected[team.Name], team.Members)
+				}
+			}
+		})
+	}
+}
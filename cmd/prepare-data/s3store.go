@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3RequestTimeout limits every request to the bucket.
+const s3RequestTimeout = time.Minute
+
+// s3Store keeps the snapshots as objects below a prefix of an S3 bucket.
+// Requests are signed with AWS signature version 4 using the credentials of
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL select S3 compatible services
+// like MinIO, which are addressed path-style.
+type s3Store struct {
+	endpoint *url.URL
+	// pathStyle puts the bucket in the path instead of the host name.
+	pathStyle bool
+	bucket    string
+	prefix    string
+	region    string
+
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func newS3Store(location string) (*s3Store, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("Invalid snapshot location '%s': expected s3://bucket/prefix", location)
+	}
+
+	s := &s3Store{
+		bucket:       u.Host,
+		prefix:       strings.Trim(u.Path, "/"),
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("Snapshots in S3 need AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint != "" {
+		s.pathStyle = true
+	} else {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.region)
+	}
+	s.endpoint, err = url.Parse(endpoint)
+	if err != nil || s.endpoint.Host == "" {
+		return nil, fmt.Errorf("Invalid S3 endpoint '%s'", endpoint)
+	}
+
+	return s, nil
+}
+
+func (s *s3Store) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "/" + name
+}
+
+func (s *s3Store) location(key string) string {
+	return "s3://" + s.bucket + "/" + key
+}
+
+// objectURL returns the URL of key, or of the bucket if key is empty.
+func (s *s3Store) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	objectPath := "/" + key
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = path.Clean(strings.TrimSuffix(u.Path, "/") + objectPath)
+	if key == "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	}
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+func (s *s3Store) do(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3RequestTimeout)
+	defer cancel()
+
+	u := s.objectURL(key, query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("Error constructing S3 request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	s.sign(req, body, time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending S3 request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading S3 response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		xml.Unmarshal(respBody, &s3Err)
+		return nil, fmt.Errorf("Unexpected status %d for %s %s: %s %s", resp.StatusCode, method, s.location(key), s3Err.Code, s3Err.Message)
+	}
+
+	return respBody, nil
+}
+
+// sign adds the AWS signature version 4 Authorization header to req,
+// signing the host and every header already set.
+func (s *s3Store) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	payload := hex.EncodeToString(payloadHash[:])
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and
+// slashes unless escapeSlash is set.
+func s3Escape(value string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func (s *s3Store) save(ctx context.Context, snapshot Snapshot) (string, error) {
+	data, err := marshalIndented(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	key := s.key(snapshotFileName(snapshot.TakenAt))
+	_, err = s.do(ctx, "PUT", key, nil, data)
+	if err != nil {
+		return "", fmt.Errorf("Error writing snapshot '%s': %w", s.location(key), err)
+	}
+
+	return s.location(key), nil
+}
+
+func (s *s3Store) list(ctx context.Context) ([]snapshotRef, error) {
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+
+	refs := []snapshotRef{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := s.do(ctx, "GET", "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Error listing snapshots in '%s': %w", s.location(prefix), err)
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.Unmarshal(body, &result)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing snapshot list of '%s': %w", s.location(prefix), err)
+		}
+
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, prefix)
+			takenAt, err := time.Parse(snapshotTimeFormat+".json", name)
+			if err != nil {
+				// Not written by save, or in a nested prefix.
+				continue
+			}
+			refs = append(refs, snapshotRef{location: s.location(object.Key), takenAt: takenAt})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sortSnapshotRefs(refs)
+
+	return refs, nil
+}
+
+func (s *s3Store) load(ctx context.Context, ref snapshotRef) (Snapshot, error) {
+	key := s.key(snapshotFileName(ref.takenAt))
+	data, err := s.do(ctx, "GET", key, nil, nil)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Error reading snapshot '%s': %w", ref.location, err)
+	}
+	return parseSnapshot(ref.location, data)
+}
+
+func (s *s3Store) close() error {
+	return nil
+}
+
+func (s *s3Store) remove(ctx context.Context, ref snapshotRef) error {
+	_, err := s.do(ctx, "DELETE", s.key(snapshotFileName(ref.takenAt)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("Error removing snapshot '%s': %w", ref.location, err)
+	}
+	return nil
+}
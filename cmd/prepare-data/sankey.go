@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	sankeyJoined = "(joined)"
+	sankeyLeft   = "(left)"
+)
+
+type Sankey struct {
+	Nodes []SankeyNode `json:"nodes"`
+	Links []SankeyLink `json:"links"`
+}
+
+type SankeyNode struct {
+	Name     string `json:"name"`
+	Team     string `json:"team"`
+	Snapshot string `json:"snapshot"`
+	Stage    int    `json:"stage"`
+}
+
+// SankeyLink is a flow of people between two teams. Every person adds up to
+// one to the values of their links, split evenly when they are in several
+// teams, so the flows between two stages add up to the headcount.
+type SankeyLink struct {
+	Source  int      `json:"source"`
+	Target  int      `json:"target"`
+	Value   float64  `json:"value"`
+	Members []string `json:"members,omitempty"`
+}
+
+// toSankey turns consecutive snapshots into flows of people between teams.
+// Every stage gets its own set of nodes so the result is acyclic. People
+// appearing or disappearing between two snapshots flow from "(joined)" or
+// into "(left)". Teams renamed by the next snapshot are named like in the
+// next snapshot already, so they continue instead of flowing into a new
+// team.
+func toSankey(snapshots []Snapshot) (*Sankey, error) {
+	if len(snapshots) < 2 {
+		return nil, fmt.Errorf("Need at least two snapshots to compute member movement, found %d", len(snapshots))
+	}
+
+	s := &Sankey{Nodes: []SankeyNode{}, Links: []SankeyLink{}}
+	nodeIndex := map[string]int{}
+
+	node := func(stage int, team string) int {
+		key := fmt.Sprintf("%d/%s", stage, team)
+		if i, ok := nodeIndex[key]; ok {
+			return i
+		}
+		date := snapshots[stage].TakenAt.Format("2006-01-02")
+		s.Nodes = append(s.Nodes, SankeyNode{Name: fmt.Sprintf("%s @ %s", team, date), Team: team, Snapshot: date, Stage: stage})
+		nodeIndex[key] = len(s.Nodes) - 1
+		return nodeIndex[key]
+	}
+
+	stageTeams := make([][]Team, len(snapshots))
+	for stage, snapshot := range snapshots {
+		stageTeams[stage] = snapshot.Teams
+		if stage+1 < len(snapshots) {
+			stageTeams[stage] = renamedTeams(snapshot.Teams, snapshotRenames(snapshot, snapshots[stage+1]))
+		}
+	}
+
+	for stage := 0; stage < len(snapshots)-1; stage++ {
+		before := teamsByMember(stageTeams[stage])
+		after := teamsByMember(stageTeams[stage+1])
+
+		members := []string{}
+		for member := range before {
+			members = append(members, member)
+		}
+		for member := range after {
+			if _, ok := before[member]; !ok {
+				members = append(members, member)
+			}
+		}
+		sort.Strings(members)
+
+		links := map[[2]int]*SankeyLink{}
+		linkOrder := [][2]int{}
+
+		for _, member := range members {
+			sources := before[member]
+			if len(sources) == 0 {
+				sources = []string{sankeyJoined}
+			}
+			targets := after[member]
+			if len(targets) == 0 {
+				targets = []string{sankeyLeft}
+			}
+
+			weight := 1 / float64(len(sources)*len(targets))
+			for _, source := range sources {
+				for _, target := range targets {
+					key := [2]int{node(stage, source), node(stage+1, target)}
+					link, ok := links[key]
+					if !ok {
+						link = &SankeyLink{Source: key[0], Target: key[1], Members: []string{}}
+						links[key] = link
+						linkOrder = append(linkOrder, key)
+					}
+					link.Value += weight
+					link.Members = append(link.Members, member)
+				}
+			}
+		}
+
+		for _, key := range linkOrder {
+			link := *links[key]
+			link.Value = math.Round(link.Value*1000) / 1000
+			s.Links = append(s.Links, link)
+		}
+	}
+
+	return s, nil
+}
+
+func teamsByMember(teams []Team) map[string][]string {
+	result := map[string][]string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			result[member] = append(result[member], team.Name)
+		}
+	}
+	for _, names := range result {
+		sort.Strings(names)
+	}
+	return result
+}
+
+func encodeSankey(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.snapshotDir == "" {
+		return nil, fmt.Errorf("The sankey format requires --snapshot-dir")
+	}
+
+	snapshots, err := loadSnapshots(ctx, opts.snapshots, opts.since, opts.until)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := toSankey(snapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.privacyMode {
+		for i := range s.Links {
+			s.Links[i].Members = nil
+		}
+	}
+
+	return marshalIndented(s)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func sankeySnapshots(stages ...[]Team) []Snapshot {
+	snapshots := []Snapshot{}
+	for i, teams := range stages {
+		snapshots = append(snapshots, Snapshot{TakenAt: time.Date(2026, 1, 1+i, 0, 0, 0, 0, time.UTC), Teams: teams})
+	}
+	return snapshots
+}
+
+// sankeyFlows maps "source -> target" team names of the first stage to the
+// link values.
+func sankeyFlows(s *Sankey) map[string]float64 {
+	flows := map[string]float64{}
+	for _, link := range s.Links {
+		flows[s.Nodes[link.Source].Team+" -> "+s.Nodes[link.Target].Team] += link.Value
+	}
+	return flows
+}
+
+func TestToSankey(t *testing.T) {
+	cases := map[string]struct {
+		snapshots []Snapshot
+		flows     map[string]float64
+	}{
+		"stay in two teams": {
+			snapshots: sankeySnapshots(
+				[]Team{{Name: "team-a", Members: []string{"alice"}}, {Name: "team-b", Members: []string{"alice"}}},
+				[]Team{{Name: "team-a", Members: []string{"alice"}}, {Name: "team-b", Members: []string{"alice"}}},
+			),
+			flows: map[string]float64{"team-a -> team-a": 0.25, "team-a -> team-b": 0.25, "team-b -> team-a": 0.25, "team-b -> team-b": 0.25},
+		},
+		"join and leave": {
+			snapshots: sankeySnapshots(
+				[]Team{{Name: "team-a", Members: []string{"alice", "bob"}}},
+				[]Team{{Name: "team-a", Members: []string{"alice", "carol"}}},
+			),
+			flows: map[string]float64{"team-a -> team-a": 1, "team-a -> " + sankeyLeft: 1, sankeyJoined + " -> team-a": 1},
+		},
+		"move to two teams": {
+			snapshots: sankeySnapshots(
+				[]Team{{Name: "team-a", Members: []string{"alice"}}},
+				[]Team{{Name: "team-b", Members: []string{"alice", "bob", "dave"}}, {Name: "team-c", Members: []string{"alice", "carol", "erin"}}},
+			),
+			flows: map[string]float64{"team-a -> team-b": 0.5, "team-a -> team-c": 0.5, sankeyJoined + " -> team-b": 2, sankeyJoined + " -> team-c": 2},
+		},
+		"recorded rename": {
+			snapshots: func() []Snapshot {
+				s := sankeySnapshots(
+					[]Team{{Name: "team-a", Members: []string{"alice", "bob", "carol"}}, {Name: "team-d", Members: []string{"dave"}}},
+					[]Team{{Name: "team-b", Members: []string{"alice", "bob", "carol"}}, {Name: "team-d", Members: []string{"dave"}}},
+				)
+				s[1].Renames = []TeamRename{{From: "team-a", To: "team-b", Similarity: 1}}
+				return s
+			}(),
+			flows: map[string]float64{"team-b -> team-b": 3, "team-d -> team-d": 1},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			s, err := toSankey(c.snapshots)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			flows := sankeyFlows(s)
+			if len(flows) != len(c.flows) {
+				t.Errorf("expected flows %v, got %v", c.flows, flows)
+			}
+			for flow, value := range c.flows {
+				if flows[flow] != value {
+					t.Errorf("expected %s to be %g, got %g", flow, value, flows[flow])
+				}
+			}
+		})
+	}
+}
+
+func TestToSankeyFlowsAddUpToHeadcount(t *testing.T) {
+	s, err := toSankey(sankeySnapshots(
+		[]Team{{Name: "team-a", Members: []string{"alice", "bob"}}, {Name: "team-b", Members: []string{"alice", "carol"}}, {Name: "team-c", Members: []string{"alice"}}},
+		[]Team{{Name: "team-a", Members: []string{"alice", "bob"}}, {Name: "team-b", Members: []string{"carol"}}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0.0
+	for _, link := range s.Links {
+		total += link.Value
+	}
+	if total < 2.99 || total > 3.01 {
+		t.Errorf("expected the flows of alice, bob and carol to add up to 3, got %g", total)
+	}
+}
+
+func TestToSankeyNeedsTwoSnapshots(t *testing.T) {
+	_, err := toSankey(sankeySnapshots([]Team{{Name: "team-a"}}))
+	if err == nil {
+		t.Errorf("expected an error for a single snapshot")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// toSARIF converts lint findings to a SARIF log for code scanning. Findings
+// are located at repo/path, relative to a checkout of the organization.
+func toSARIF(rules []lintRule, findings []Finding) sarifLog {
+	driver := sarifDriver{
+		Name:           "org-vis",
+		Version:        version,
+		InformationURI: "https://github.com/" + releaseRepo,
+		Rules:          []sarifRule{},
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.name, ShortDescription: sarifMessage{Text: rule.description}})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range findings {
+		result := sarifResult{RuleID: finding.Rule, Level: "warning", Message: sarifMessage{Text: finding.Message}}
+		if finding.Repo != "" {
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.Repo}}
+			if finding.Path != "" {
+				location.ArtifactLocation.URI += "/" + finding.Path
+				if finding.Line > 0 {
+					location.Region = &sarifRegion{StartLine: finding.Line}
+				}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		results = append(results, result)
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+func writeSARIF(path string, rules []lintRule, findings []Finding) error {
+	sarifBytes, err := marshalIndented(toSARIF(rules, findings))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("writing sarif to %s\n", path)
+	err = os.WriteFile(path, sarifBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing sarif file: %w", err)
+	}
+
+	return nil
+}
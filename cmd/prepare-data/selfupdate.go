@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+const releaseRepo = "giantswarm/org-vis"
+
+// version and releasePublicKey are set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.releasePublicKey=<base64>"
+//
+// releasePublicKey is the ed25519 key the checksums file of a release is
+// signed with.
+var (
+	version          = "dev"
+	releasePublicKey = ""
+)
+
+type Release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func fetchLatestRelease(ctx context.Context, repo string) (Release, error) {
+	var release Release
+
+	releaseBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/repos/%s/releases/latest", github.PublicAPIURL, repo))
+	var notFound *github.NotFoundError
+	if errors.As(err, &notFound) {
+		return release, fmt.Errorf("No release found for %s", repo)
+	}
+	if err != nil {
+		return release, err
+	}
+
+	err = json.Unmarshal(releaseBytes, &release)
+	if err != nil {
+		return release, fmt.Errorf("Error parsing release json: %w", err)
+	}
+	return release, nil
+}
+
+func (r Release) asset(name string) (ReleaseAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return ReleaseAsset{}, false
+}
+
+func downloadAsset(ctx context.Context, a ReleaseAsset) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error constructing request for '%s': %w", a.Name, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error downloading '%s': %w", a.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error downloading '%s': %s", a.Name, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading '%s': %w", a.Name, err)
+	}
+
+	return data, nil
+}
+
+// releaseChecksum looks up name in a checksums file in sha256sum format.
+func releaseChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("No checksum for '%s' in release", name)
+}
+
+func verifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("Invalid public key, expected a base64 encoded ed25519 key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("Error decoding signature: %w", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("Signature does not match the public key")
+	}
+
+	return nil
+}
+
+// replaceExecutable writes the new binary next to the running one and
+// renames it into place, so a failed update leaves the old binary intact.
+func replaceExecutable(data []byte) (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("Error locating executable: %w", err)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("Error resolving executable path: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return "", fmt.Errorf("Error creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0755)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("Error writing new executable: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", fmt.Errorf("Error replacing executable '%s': %w", path, err)
+	}
+
+	return path, nil
+}
+
+func runSelfUpdate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := fs.Bool("check", false, "Only report whether a newer release is available")
+	force := fs.Bool("force", false, "Reinstall even if the latest release is already installed")
+	skipSignature := fs.Bool("insecure-skip-signature", false, "Only verify the checksum when the binary was built without a release public key")
+	fs.Parse(args)
+
+	release, err := fetchLatestRelease(ctx, releaseRepo)
+	if err != nil {
+		return err
+	}
+
+	if release.TagName == version && !*force {
+		log.Printf("org-vis %s is up to date\n", version)
+		return nil
+	}
+	if *check {
+		log.Printf("org-vis %s is available (installed: %s)\n", release.TagName, version)
+		return nil
+	}
+
+	name := fmt.Sprintf("org-vis-%s-%s", runtime.GOOS, runtime.GOARCH)
+	binaryAsset, ok := release.asset(name)
+	if !ok {
+		return fmt.Errorf("Release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsAsset, ok := release.asset("checksums.txt")
+	if !ok {
+		return fmt.Errorf("Release %s has no checksums.txt", release.TagName)
+	}
+
+	checksums, err := downloadAsset(ctx, checksumsAsset)
+	if err != nil {
+		return err
+	}
+
+	if releasePublicKey != "" {
+		signatureAsset, ok := release.asset("checksums.txt.sig")
+		if !ok {
+			return fmt.Errorf("Release %s has no checksums.txt.sig", release.TagName)
+		}
+		signature, err := downloadAsset(ctx, signatureAsset)
+		if err != nil {
+			return err
+		}
+		err = verifySignature(checksums, signature, releasePublicKey)
+		if err != nil {
+			return fmt.Errorf("Error verifying checksums of release %s: %w", release.TagName, err)
+		}
+	} else if !*skipSignature {
+		return fmt.Errorf("This build has no release public key to verify signatures with, rerun with --insecure-skip-signature to only verify checksums")
+	}
+
+	expected, err := releaseChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	binary, err := downloadAsset(ctx, binaryAsset)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(binary)
+	if hex.EncodeToString(sum[:]) != strings.ToLower(expected) {
+		return fmt.Errorf("Checksum of '%s' does not match release checksums", name)
+	}
+
+	path, err := replaceExecutable(binary)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("updated %s from %s to %s\n", path, version, release.TagName)
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// d3 4.13.0 is vendored into the frontend together with its license, so
+// the binary works offline. Run go generate to update it.
+//
+//go:generate curl -fsSL -o frontend/d3.v4.min.js https://cdn.jsdelivr.net/npm/d3@4.13.0/build/d3.min.js
+//go:generate curl -fsSL -o frontend/d3.LICENSE https://cdn.jsdelivr.net/npm/d3@4.13.0/LICENSE
+
+//go:embed frontend
+var frontendFiles embed.FS
+
+// frontendD3 is the vendored d3 the frontend loads.
+const frontendD3 = "d3.v4.min.js"
+
+// server serves the embedded frontend together with the most recently
+// rendered outputs and the membership API, which are kept in memory only.
+type server struct {
+	mu      sync.RWMutex
+	outputs map[string][]byte
+	teams   []Team
+	opts    *options
+	files   http.Handler
+
+	// publishMu serializes the polls with refreshes of single teams.
+	publishMu    sync.Mutex
+	refreshToken string
+}
+
+func newServer(refreshToken string) (*server, error) {
+	files, err := fs.Sub(frontendFiles, "frontend")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(files, frontendD3); err != nil {
+		return nil, fmt.Errorf("The frontend was built without %s, run go generate in cmd/prepare-data before building: %w", frontendD3, err)
+	}
+
+	return &server{outputs: map[string][]byte{}, files: http.FileServer(http.FS(files)), refreshToken: refreshToken}, nil
+}
+
+func (s *server) publish(ctx context.Context, teams []Team, opts *options) error {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	return s.publishLocked(ctx, teams, opts)
+}
+
+func (s *server) publishLocked(ctx context.Context, teams []Team, opts *options) error {
+	err := storeSnapshot(ctx, teams, opts)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := renderOutputs(ctx, teams, opts)
+	if err != nil {
+		return err
+	}
+
+	rendered := map[string][]byte{}
+	for _, o := range outputs {
+		rendered["/"+path.Base(o.format.output)] = o.data
+	}
+
+	s.mu.Lock()
+	s.outputs = rendered
+	s.teams = teams
+	s.opts = opts
+	s.mu.Unlock()
+
+	progress.Printf("serving %d updated outputs\n", len(outputs))
+
+	return nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	data, ok := s.outputs[r.URL.Path]
+	ready := len(s.outputs) > 0
+	teams := s.teams
+	privacy := s.opts != nil && s.opts.privacyMode
+	s.mu.RUnlock()
+
+	if r.URL.Path == "/api/refresh" {
+		s.serveRefresh(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		// Every other endpoint lists member logins.
+		if privacy {
+			writeAPIError(w, http.StatusForbidden, "the member endpoints are disabled in privacy mode")
+			return
+		}
+		serveAPI(w, r, teams)
+		return
+	}
+
+	if ok {
+		contentType := mime.TypeByExtension(path.Ext(r.URL.Path))
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+		return
+	}
+
+	if !ready && path.Ext(r.URL.Path) != "" && path.Ext(r.URL.Path) != ".html" && path.Ext(r.URL.Path) != ".js" {
+		http.Error(w, "data is not available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.files.ServeHTTP(w, r)
+}
+
+// serve keeps the outputs up to date like watch mode does and serves them
+// over HTTP, without touching the filesystem.
+func serve(ctx context.Context, base *options, opts *options) error {
+	s, err := newServer(opts.refreshToken)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 2)
+
+	go func() {
+		errs <- watch(ctx, base, opts, s.publish)
+	}()
+
+	httpServer := &http.Server{Addr: opts.serve, Handler: s}
+	go func() {
+		log.Printf("listening on %s\n", opts.serve)
+		err := httpServer.ListenAndServe()
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		errs <- err
+	}()
+
+	err = <-errs
+	// Finish the requests in flight, watch stops by itself on cancellation.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdownErr := httpServer.Shutdown(shutdownCtx)
+	if err == nil {
+		err = shutdownErr
+	}
+
+	return err
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// Signatures use the format of the release signatures verified by
+// self-update: a base64 encoded ed25519 signature of the whole file, stored
+// next to it with a .sig suffix.
+const (
+	signatureSuffix  = ".sig"
+	provenanceSuffix = ".provenance.json"
+)
+
+// loadSigningKey reads a base64 encoded ed25519 private key, or its seed,
+// as written by keygen.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading signing key '%s': %w", path, err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding signing key '%s': %w", path, err)
+	}
+
+	switch len(key) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	}
+	return nil, fmt.Errorf("Invalid signing key '%s': expected a base64 encoded ed25519 private key", path)
+}
+
+// Provenance is an in-toto statement with a SLSA provenance predicate,
+// describing how an output was generated.
+type Provenance struct {
+	Type          string              `json:"_type"`
+	Subject       []ProvenanceSubject `json:"subject"`
+	PredicateType string              `json:"predicateType"`
+	Predicate     ProvenancePredicate `json:"predicate"`
+}
+
+type ProvenanceSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type ProvenancePredicate struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		Parameters map[string]string `json:"parameters"`
+	} `json:"invocation"`
+	Metadata struct {
+		BuildFinishedOn time.Time `json:"buildFinishedOn"`
+	} `json:"metadata"`
+	Materials []ProvenanceMaterial `json:"materials"`
+}
+
+type ProvenanceMaterial struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+func newProvenance(o output, opts *options, now time.Time) (Provenance, error) {
+	digest := sha256.Sum256(o.data)
+
+	p := Provenance{
+		Type:          "https://in-toto.io/Statement/v0.1",
+		Subject:       []ProvenanceSubject{{Name: filepath.Base(o.path), Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}},
+		PredicateType: "https://slsa.dev/provenance/v0.2",
+	}
+	p.Predicate.Builder.ID = "https://github.com/" + releaseRepo + "@" + version
+	p.Predicate.BuildType = "https://github.com/" + releaseRepo + "/output@v1"
+	p.Predicate.Invocation.Parameters = map[string]string{
+		"org":    opts.org,
+		"format": o.format.name,
+	}
+	p.Predicate.Metadata.BuildFinishedOn = now.UTC()
+
+	if opts.source != "" {
+		data, err := os.ReadFile(opts.source)
+		if err != nil {
+			return p, fmt.Errorf("Error reading team source '%s': %w", opts.source, err)
+		}
+		sourceDigest := sha256.Sum256(data)
+		p.Predicate.Materials = []ProvenanceMaterial{{URI: "file:" + filepath.Base(opts.source), Digest: map[string]string{"sha256": hex.EncodeToString(sourceDigest[:])}}}
+	} else {
+		p.Predicate.Materials = []ProvenanceMaterial{{URI: fmt.Sprintf("%s/orgs/%s", github.APIURL, opts.org)}}
+	}
+
+	return p, nil
+}
+
+func signFile(path string, data []byte, key ed25519.PrivateKey, perms filePermissions) error {
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n"
+	err := perms.writeFile(path+signatureSuffix, []byte(signature))
+	if err != nil {
+		return fmt.Errorf("Error writing signature of '%s': %w", path, err)
+	}
+	return nil
+}
+
+// writeAttestations writes the provenance and the signatures of a written
+// output, if enabled. They are only replaced together with the output, or
+// when they are missing.
+func writeAttestations(o output, opts *options, changed bool) error {
+	if opts.provenance {
+		_, err := os.Stat(o.path + provenanceSuffix)
+		if changed || err != nil {
+			p, err := newProvenance(o, opts, time.Now())
+			if err != nil {
+				return err
+			}
+			data, err := marshalIndented(p)
+			if err != nil {
+				return err
+			}
+			err = opts.perms.writeFile(o.path+provenanceSuffix, data)
+			if err != nil {
+				return fmt.Errorf("Error writing provenance of '%s': %w", o.path, err)
+			}
+			if opts.signingKey != nil {
+				err = signFile(o.path+provenanceSuffix, data, opts.signingKey, opts.perms)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if opts.signingKey != nil {
+		_, err := os.Stat(o.path + signatureSuffix)
+		if changed || err != nil {
+			return signFile(o.path, o.data, opts.signingKey, opts.perms)
+		}
+	}
+
+	return nil
+}
+
+func runKeygen(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s keygen <private-key-file>\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("Expected one private key file, got %d", flags.NArg())
+	}
+	path := flags.Arg(0)
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("Error generating key: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("Error creating private key file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintln(file, base64.StdEncoding.EncodeToString(private))
+	if err != nil {
+		return fmt.Errorf("Error writing private key file: %w", err)
+	}
+
+	// The public key goes to stdout, to be handed to whoever verifies.
+	fmt.Println(base64.StdEncoding.EncodeToString(public))
+
+	return nil
+}
+
+// runVerify checks the signatures of outputs, and that their provenance
+// matches them, if there is one.
+func runVerify(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	publicKey := flags.String("public-key", "", "base64 encoded ed25519 public key printed by keygen")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s verify --public-key <key> <file>...\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *publicKey == "" {
+		return fmt.Errorf("--public-key is required")
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("Expected at least one file to verify")
+	}
+
+	for _, path := range flags.Args() {
+		err := verifyFile(path, *publicKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: OK\n", path)
+	}
+
+	return nil
+}
+
+func verifyFile(path, publicKey string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error reading '%s': %w", path, err)
+	}
+	signature, err := os.ReadFile(path + signatureSuffix)
+	if err != nil {
+		return fmt.Errorf("Error reading signature of '%s': %w", path, err)
+	}
+	err = verifySignature(data, signature, publicKey)
+	if err != nil {
+		return fmt.Errorf("Error verifying '%s': %w", path, err)
+	}
+
+	provenanceData, err := os.ReadFile(path + provenanceSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading provenance of '%s': %w", path, err)
+	}
+	signature, err = os.ReadFile(path + provenanceSuffix + signatureSuffix)
+	if err != nil {
+		return fmt.Errorf("Error reading signature of the provenance of '%s': %w", path, err)
+	}
+	err = verifySignature(provenanceData, signature, publicKey)
+	if err != nil {
+		return fmt.Errorf("Error verifying the provenance of '%s': %w", path, err)
+	}
+
+	var p Provenance
+	err = json.Unmarshal(provenanceData, &p)
+	if err != nil {
+		return fmt.Errorf("Error parsing provenance of '%s': %w", path, err)
+	}
+	digest := sha256.Sum256(data)
+	if len(p.Subject) != 1 || p.Subject[0].Digest["sha256"] != hex.EncodeToString(digest[:]) {
+		return fmt.Errorf("Provenance of '%s' describes a different file", path)
+	}
+
+	return nil
+}
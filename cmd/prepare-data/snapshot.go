@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Snapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Teams   []Team    `json:"teams"`
+	// Renames are the teams detected as renamed since the previous snapshot.
+	Renames []TeamRename `json:"renames,omitempty"`
+}
+
+const snapshotTimeFormat = "2006-01-02T15-04-05Z"
+
+func snapshotFileName(t time.Time) string {
+	return t.UTC().Format(snapshotTimeFormat) + ".json"
+}
+
+// snapshotStore persists snapshots. Snapshots are identified by the second
+// they were taken at, so every store names them like snapshotFileName.
+type snapshotStore interface {
+	// save stores snapshot and returns its location.
+	save(ctx context.Context, snapshot Snapshot) (string, error)
+	// list returns the stored snapshots, newest first.
+	list(ctx context.Context) ([]snapshotRef, error)
+	load(ctx context.Context, ref snapshotRef) (Snapshot, error)
+	remove(ctx context.Context, ref snapshotRef) error
+	// close releases the resources of the store, it can't be used after.
+	close() error
+}
+
+type snapshotRef struct {
+	// location is where the snapshot is stored, for messages.
+	location string
+	takenAt  time.Time
+}
+
+// openSnapshotStore returns the store of location, an s3://bucket/prefix
+// URL, sqlite:file.db or a directory.
+func openSnapshotStore(location string, perms filePermissions) (snapshotStore, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		return newS3Store(location)
+	case strings.HasPrefix(location, "sqlite:"):
+		return openSQLiteStore(strings.TrimPrefix(location, "sqlite:"))
+	}
+	return dirStore{dir: location, perms: perms}, nil
+}
+
+func sortSnapshotRefs(refs []snapshotRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].takenAt.After(refs[j].takenAt)
+	})
+}
+
+// dirStore keeps every snapshot in a json file of a directory.
+type dirStore struct {
+	dir   string
+	perms filePermissions
+}
+
+func (s dirStore) save(ctx context.Context, snapshot Snapshot) (string, error) {
+	err := os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("Error creating snapshot directory '%s': %w", s.dir, err)
+	}
+
+	data, err := marshalIndented(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, snapshotFileName(snapshot.TakenAt))
+	err = s.perms.writeFile(path, data)
+	if err != nil {
+		return "", fmt.Errorf("Error writing snapshot '%s': %w", path, err)
+	}
+
+	return path, nil
+}
+
+func (s dirStore) list(ctx context.Context) ([]snapshotRef, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading snapshot directory '%s': %w", s.dir, err)
+	}
+
+	refs := []snapshotRef{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		takenAt, err := time.Parse(snapshotTimeFormat+".json", entry.Name())
+		if err != nil {
+			// Not written by save.
+			continue
+		}
+		refs = append(refs, snapshotRef{location: filepath.Join(s.dir, entry.Name()), takenAt: takenAt})
+	}
+	sortSnapshotRefs(refs)
+
+	return refs, nil
+}
+
+func (s dirStore) load(ctx context.Context, ref snapshotRef) (Snapshot, error) {
+	return readSnapshot(ref.location)
+}
+
+func (s dirStore) remove(ctx context.Context, ref snapshotRef) error {
+	err := os.Remove(ref.location)
+	if err != nil {
+		return fmt.Errorf("Error removing snapshot '%s': %w", ref.location, err)
+	}
+	return nil
+}
+
+func (s dirStore) close() error {
+	return nil
+}
+
+// closeSnapshotStore closes the store of opts, if any, logging failures.
+func closeSnapshotStore(opts *options) {
+	if opts.snapshots == nil {
+		return
+	}
+	err := opts.snapshots.close()
+	if err != nil {
+		log.Printf("Error closing snapshot store '%s': %v\n", opts.snapshotDir, err)
+	}
+}
+
+func readSnapshot(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Error reading snapshot '%s': %w", path, err)
+	}
+
+	return parseSnapshot(path, data)
+}
+
+func parseSnapshot(location string, data []byte) (Snapshot, error) {
+	var snapshot Snapshot
+
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return snapshot, fmt.Errorf("Error parsing snapshot '%s': %w", location, err)
+	}
+
+	return snapshot, nil
+}
+
+// latestSnapshot returns the newest snapshot in store, or nil if there is
+// none yet.
+func latestSnapshot(ctx context.Context, store snapshotStore) (*Snapshot, error) {
+	refs, err := store.list(ctx)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	snapshot, err := store.load(ctx, refs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// loadSnapshots returns the snapshots in store taken within [since, until],
+// oldest first. Zero times leave the respective end of the range open.
+func loadSnapshots(ctx context.Context, store snapshotStore, since, until time.Time) ([]Snapshot, error) {
+	refs, err := store.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+
+	for i := len(refs) - 1; i >= 0; i-- {
+		ref := refs[i]
+		if !since.IsZero() && ref.takenAt.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !ref.takenAt.Before(until.AddDate(0, 0, 1)) {
+			continue
+		}
+
+		snapshot, err := store.load(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceTeam is a team of a local team definition file.
+type SourceTeam struct {
+	Name string `yaml:"name"`
+	// Slug defaults to the slug GitHub derives from the name.
+	Slug    string `yaml:"slug"`
+	Privacy string `yaml:"privacy"`
+	// Parent is the name or slug of the parent team.
+	Parent      string   `yaml:"parent"`
+	Members     []string `yaml:"members"`
+	Maintainers []string `yaml:"maintainers"`
+}
+
+type teamSource struct {
+	Teams []SourceTeam `yaml:"teams"`
+}
+
+var nonSlugPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// teamSlug approximates how GitHub derives a team slug from its name.
+func teamSlug(name string) string {
+	return strings.Trim(nonSlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// loadTeamSource reads teams from a YAML team definition, or from the json
+// of `terraform show -json` for a plan or state managing the teams with the
+// GitHub provider. A plan previews the teams after it is applied.
+func loadTeamSource(path string, filter teamFilter) ([]Team, error) {
+	progress.Printf("reading teams from %s\n", path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading team source: %w", err)
+	}
+
+	var source teamSource
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		source, err = parseTerraformTeams(data)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing terraform json '%s': %w", path, err)
+		}
+	} else {
+		err = yaml.Unmarshal(data, &source)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing team source '%s': %w", path, err)
+		}
+	}
+
+	return source.teams(filter)
+}
+
+func (s teamSource) teams(filter teamFilter) ([]Team, error) {
+	refs := map[string]TeamRef{}
+	for i, team := range s.Teams {
+		if team.Name == "" {
+			return nil, fmt.Errorf("Team %d of the team source has no name", i+1)
+		}
+		if team.Slug == "" {
+			s.Teams[i].Slug = teamSlug(team.Name)
+		}
+		ref := TeamRef{Name: team.Name, Slug: s.Teams[i].Slug}
+		refs[strings.ToLower(ref.Name)] = ref
+		refs[strings.ToLower(ref.Slug)] = ref
+	}
+
+	all := []Team{}
+
+	for _, sourceTeam := range s.Teams {
+		team := Team{Name: sourceTeam.Name, Slug: sourceTeam.Slug, Privacy: sourceTeam.Privacy}
+		if team.Privacy == "" {
+			team.Privacy = privacySecret
+		}
+		if sourceTeam.Parent != "" {
+			parent, ok := refs[strings.ToLower(sourceTeam.Parent)]
+			if !ok {
+				return nil, fmt.Errorf("Unknown parent team '%s' of team '%s'", sourceTeam.Parent, team.Name)
+			}
+			team.Parent = &parent
+		}
+
+		team.Members = []string{}
+		team.Maintainers = []string{}
+		for _, login := range sourceTeam.Maintainers {
+			if !contains(team.Maintainers, login) {
+				team.Maintainers = append(team.Maintainers, login)
+			}
+		}
+		logins := append(append([]string{}, sourceTeam.Maintainers...), sourceTeam.Members...)
+		for _, login := range logins {
+			if contains(team.Members, login) || !roleMatches(filter.role(), memberRole(team, login)) {
+				continue
+			}
+			team.Members = append(team.Members, login)
+		}
+		sort.Strings(team.Members)
+
+		all = append(all, team)
+	}
+
+	// Like the GitHub API, teams include the members of their child teams,
+	// also of the ones the filter leaves out.
+	rollupMembers(all)
+
+	teams := []Team{}
+	for _, team := range all {
+		team = filter.names.normalize(team)
+		if filter.includes(team) {
+			teams = append(teams, team)
+		}
+	}
+
+	return teams, nil
+}
+
+type terraformModule struct {
+	Address   string `json:"address"`
+	Resources []struct {
+		Address string                 `json:"address"`
+		Type    string                 `json:"type"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []terraformModule `json:"child_modules"`
+}
+
+type terraformMember struct {
+	login string
+	role  string
+}
+
+type terraformModuleConfig struct {
+	Resources []struct {
+		Address     string `json:"address"`
+		Expressions map[string]struct {
+			References []string `json:"references"`
+		} `json:"expressions"`
+	} `json:"resources"`
+	ModuleCalls map[string]struct {
+		Module terraformModuleConfig `json:"module"`
+	} `json:"module_calls"`
+}
+
+// terraformReferences maps resource addresses to the team resource their
+// team_id or parent_team_id refers to. Plans only know the ids of teams that
+// already exist, new ones are resolved from the configuration.
+func (c terraformModuleConfig) terraformReferences(prefix string, refs map[string]string) {
+	for _, resource := range c.Resources {
+		for _, key := range []string{"team_id", "parent_team_id"} {
+			for _, reference := range resource.Expressions[key].References {
+				if strings.HasPrefix(reference, "github_team.") && strings.Count(reference, ".") == 1 {
+					refs[prefix+resource.Address+"."+key] = prefix + reference
+				}
+			}
+		}
+	}
+	for name, call := range c.ModuleCalls {
+		call.Module.terraformReferences(prefix+"module."+name+".", refs)
+	}
+}
+
+func parseTerraformTeams(data []byte) (teamSource, error) {
+	var show struct {
+		Values *struct {
+			RootModule terraformModule `json:"root_module"`
+		} `json:"values"`
+		PlannedValues *struct {
+			RootModule terraformModule `json:"root_module"`
+		} `json:"planned_values"`
+		Configuration struct {
+			RootModule terraformModuleConfig `json:"root_module"`
+		} `json:"configuration"`
+	}
+	err := json.Unmarshal(data, &show)
+	if err != nil {
+		return teamSource{}, err
+	}
+
+	var root terraformModule
+	switch {
+	case show.PlannedValues != nil:
+		root = show.PlannedValues.RootModule
+	case show.Values != nil:
+		root = show.Values.RootModule
+	default:
+		return teamSource{}, fmt.Errorf("neither planned_values nor values found, expected the output of terraform show -json")
+	}
+
+	refs := map[string]string{}
+	show.Configuration.RootModule.terraformReferences("", refs)
+
+	source := teamSource{}
+	// Teams are looked up by resource address, id, name and slug.
+	teamIndex := map[string]int{}
+	members := map[int][]terraformMember{}
+	parents := map[int]string{}
+
+	str := func(values map[string]interface{}, key string) string {
+		switch value := values[key].(type) {
+		case string:
+			return value
+		case float64:
+			return fmt.Sprintf("%.0f", value)
+		}
+		return ""
+	}
+
+	var collect func(module terraformModule)
+	collect = func(module terraformModule) {
+		for _, resource := range module.Resources {
+			if resource.Type != "github_team" {
+				continue
+			}
+			team := SourceTeam{Name: str(resource.Values, "name"), Slug: str(resource.Values, "slug"), Privacy: str(resource.Values, "privacy")}
+			index := len(source.Teams)
+			source.Teams = append(source.Teams, team)
+			for _, key := range []string{resource.Address, str(resource.Values, "id"), team.Name, team.Slug} {
+				if key != "" {
+					teamIndex[key] = index
+				}
+			}
+			parents[index] = str(resource.Values, "parent_team_id")
+			if parents[index] == "" {
+				parents[index] = refs[resource.Address+".parent_team_id"]
+			}
+		}
+		for _, child := range module.ChildModules {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	lookup := func(id, address string) (int, bool) {
+		if index, ok := teamIndex[id]; id != "" && ok {
+			return index, true
+		}
+		index, ok := teamIndex[refs[address+".team_id"]]
+		return index, ok
+	}
+
+	var collectMembers func(module terraformModule)
+	collectMembers = func(module terraformModule) {
+		for _, resource := range module.Resources {
+			switch resource.Type {
+			case "github_team_membership":
+				index, ok := lookup(str(resource.Values, "team_id"), resource.Address)
+				if !ok {
+					continue
+				}
+				members[index] = append(members[index], terraformMember{login: str(resource.Values, "username"), role: str(resource.Values, "role")})
+			case "github_team_members":
+				index, ok := lookup(str(resource.Values, "team_id"), resource.Address)
+				if !ok {
+					continue
+				}
+				list, _ := resource.Values["members"].([]interface{})
+				for _, item := range list {
+					member, _ := item.(map[string]interface{})
+					members[index] = append(members[index], terraformMember{login: str(member, "username"), role: str(member, "role")})
+				}
+			}
+		}
+		for _, child := range module.ChildModules {
+			collectMembers(child)
+		}
+	}
+	collectMembers(root)
+
+	for index := range source.Teams {
+		team := &source.Teams[index]
+		if parent, ok := teamIndex[parents[index]]; ok {
+			team.Parent = source.Teams[parent].Name
+		}
+		for _, member := range members[index] {
+			if member.role == roleMaintainer {
+				team.Maintainers = append(team.Maintainers, member.login)
+			} else {
+				team.Members = append(team.Members, member.login)
+			}
+		}
+	}
+
+	return source, nil
+}
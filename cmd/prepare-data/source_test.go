@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadTeamSourceIncludesChildMembers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	err := os.WriteFile(path, []byte(`teams:
+  - name: team-parent
+    members: [alice, bob]
+  - name: team-child
+    parent: team-parent
+    members: [bob, carol]
+  - name: child-engineers
+    parent: team-child
+    members: [dave]
+  - name: team-other
+    members: [erin]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := loadTeamSource(path, defaultTeamFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		// child-engineers is filtered out, but still counts for its
+		// ancestors.
+		"team-parent": {"alice", "bob", "carol", "dave"},
+		"team-child":  {"bob", "carol", "dave"},
+		"team-other":  {"erin"},
+	}
+	if len(teams) != len(expected) {
+		t.Fatalf("expected %d teams, got %v", len(expected), teams)
+	}
+	for _, team := range teams {
+		if !reflect.DeepEqual(team.Members, expected[team.Name]) {
+			t.Errorf("expected %s to have %v, got %v", team.Name, expected[team.Name], team.Members)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build sqlite
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore keeps the snapshots in a table of a SQLite database. It
+// needs cgo, so it is only built with -tags sqlite.
+type sqliteStore struct {
+	path string
+	db   *sql.DB
+}
+
+func openSQLiteStore(path string) (snapshotStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening snapshot database '%s': %w", path, err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS snapshots (taken_at TEXT PRIMARY KEY, data BLOB NOT NULL)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error creating snapshot table in '%s': %w", path, err)
+	}
+
+	return &sqliteStore{path: path, db: db}, nil
+}
+
+func (s *sqliteStore) location(takenAt time.Time) string {
+	return "sqlite:" + s.path + "#" + takenAt.UTC().Format(snapshotTimeFormat)
+}
+
+func (s *sqliteStore) save(ctx context.Context, snapshot Snapshot) (string, error) {
+	data, err := marshalIndented(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	// The time format sorts chronologically as text.
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO snapshots (taken_at, data) VALUES (?, ?)`, snapshot.TakenAt.UTC().Format(snapshotTimeFormat), data)
+	if err != nil {
+		return "", fmt.Errorf("Error writing snapshot to '%s': %w", s.path, err)
+	}
+
+	return s.location(snapshot.TakenAt), nil
+}
+
+func (s *sqliteStore) list(ctx context.Context) ([]snapshotRef, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT taken_at FROM snapshots ORDER BY taken_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing snapshots in '%s': %w", s.path, err)
+	}
+	defer rows.Close()
+
+	refs := []snapshotRef{}
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("Error listing snapshots in '%s': %w", s.path, err)
+		}
+		takenAt, err := time.Parse(snapshotTimeFormat, name)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, snapshotRef{location: s.location(takenAt), takenAt: takenAt})
+	}
+
+	return refs, rows.Err()
+}
+
+func (s *sqliteStore) load(ctx context.Context, ref snapshotRef) (Snapshot, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM snapshots WHERE taken_at = ?`, ref.takenAt.UTC().Format(snapshotTimeFormat)).Scan(&data)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Error reading snapshot '%s': %w", ref.location, err)
+	}
+	return parseSnapshot(ref.location, data)
+}
+
+func (s *sqliteStore) remove(ctx context.Context, ref snapshotRef) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM snapshots WHERE taken_at = ?`, ref.takenAt.UTC().Format(snapshotTimeFormat))
+	if err != nil {
+		return fmt.Errorf("Error removing snapshot '%s': %w", ref.location, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) close() error {
+	return s.db.Close()
+}
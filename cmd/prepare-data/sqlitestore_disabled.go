@@ -0,0 +1,9 @@
+//go:build !sqlite
+
+package main
+
+import "fmt"
+
+func openSQLiteStore(path string) (snapshotStore, error) {
+	return nil, fmt.Errorf("This build has no SQLite support for '%s', rebuild with -tags sqlite", path)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+// progress logs what a run is doing. It is silenced by --quiet, unlike
+// errors and warnings which always go to the standard logger.
+var progress = log.New(os.Stderr, "", log.LstdFlags)
+
+// runSummary is printed to stdout with --summary-json at the end of a
+// one-shot run.
+type runSummary struct {
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Teams           int       `json:"teams"`
+	Members         int       `json:"members"`
+	Outputs         int       `json:"outputs"`
+	ChangedOutputs  []string  `json:"changed_outputs"`
+	StaleOutputs    []string  `json:"stale_outputs,omitempty"`
+	Alerts          []Alert   `json:"alerts,omitempty"`
+	Changed         bool      `json:"changed"`
+	ExitCode        int       `json:"exit_code"`
+	Error           string    `json:"error,omitempty"`
+
+	RateLimits []github.RateLimitUsage `json:"rate_limits"`
+}
+
+// logThrottle logs waits for a rate limit, leaving out the short pauses
+// of pacing.
+func logThrottle(resource string, wait time.Duration) {
+	if wait < time.Second {
+		return
+	}
+	progress.Printf("%s rate limit nearly exhausted, waiting %s\n", resource, wait.Round(time.Second))
+}
+
+// logRateLimits logs how much of each rate limit was used so far.
+func logRateLimits() {
+	for _, usage := range github.RateLimits() {
+		progress.Printf("%s rate limit: used %d, %d of %d left until %s\n", usage.Resource, usage.Consumed, usage.Remaining, usage.Limit, usage.Reset.Format(time.Kitchen))
+	}
+}
+
+func newRunSummary() *runSummary {
+	return &runSummary{StartedAt: time.Now().UTC(), ChangedOutputs: []string{}}
+}
+
+func (s *runSummary) addTeams(teams []Team) {
+	members := []string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if !contains(members, member) {
+				members = append(members, member)
+			}
+		}
+	}
+	s.Teams = len(teams)
+	s.Members = len(members)
+}
+
+func (s *runSummary) print() {
+	s.DurationSeconds = time.Since(s.StartedAt).Seconds()
+	s.RateLimits = github.RateLimits()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		log.Printf("Error marshaling run summary: %v\n", err)
+		return
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,55 @@
+package main
+
+import "context"
+
+// SunburstNode is one level of the org → type → team → member partition
+// hierarchy consumed by d3.partition based sunburst charts.
+type SunburstNode struct {
+	Name     string          `json:"name"`
+	Value    int             `json:"value,omitempty"`
+	Color    string          `json:"color,omitempty"`
+	Children []*SunburstNode `json:"children,omitempty"`
+}
+
+// toSunburst adds a leaf per member to the teams. Leaving out the members,
+// for privacy mode, gives the teams their number of members as value, so
+// the chart keeps its proportions.
+func toSunburst(teams []Team, org string, types taxonomy, members bool) (*SunburstNode, error) {
+	root := &SunburstNode{Name: org}
+	typeNodes := map[string]*SunburstNode{}
+
+	for _, team := range teams {
+		_, teamType, err := types.graphTeamName(org, team.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		typeNode, ok := typeNodes[teamType.Key]
+		if !ok {
+			typeNode = &SunburstNode{Name: teamType.Label, Color: teamType.Color}
+			typeNodes[teamType.Key] = typeNode
+			root.Children = append(root.Children, typeNode)
+		}
+
+		teamNode := &SunburstNode{Name: team.Name}
+		if members {
+			for _, member := range team.Members {
+				teamNode.Children = append(teamNode.Children, &SunburstNode{Name: member, Value: 1})
+			}
+		} else {
+			teamNode.Value = len(team.Members)
+		}
+		typeNode.Children = append(typeNode.Children, teamNode)
+	}
+
+	return root, nil
+}
+
+func encodeSunburst(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	root, err := toSunburst(teams, opts.org, opts.taxonomy, !opts.privacyMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalIndented(root)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"math"
+)
+
+const (
+	svgMargin = 40
+	// svgOtherColor is used for nodes that aren't teams of a known type.
+	svgOtherColor = "#999999"
+)
+
+func svgNodeColor(n Node, types taxonomy) string {
+	if teamType, ok := types.byKey(n.Type); ok && teamType.Color != "" && n.MemberCount != nil {
+		return teamType.Color
+	}
+	return svgOtherColor
+}
+
+// svgNodeRadius grows with the number of members, so that the area of a
+// node is about proportional to it.
+func svgNodeRadius(n Node) float64 {
+	if n.MemberCount == nil {
+		return 5
+	}
+	return 5 + 2*math.Sqrt(float64(*n.MemberCount))
+}
+
+// renderSVG draws g at the positions of layout. Edges are drawn first, so
+// nodes and labels stay readable on top of them.
+func renderSVG(g *Graph, layout map[string]point, size imageSize, types taxonomy) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"11\">\n", size.width, size.height, size.width, size.height)
+	buf.WriteString("  <defs>\n")
+	buf.WriteString("    <marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto-start-reverse\"><path d=\"M 0 0 L 10 5 L 0 10 z\" fill=\"#888888\"/></marker>\n")
+	buf.WriteString("  </defs>\n")
+	buf.WriteString("  <rect width=\"100%\" height=\"100%\" fill=\"#ffffff\"/>\n")
+
+	buf.WriteString("  <g stroke=\"#bbbbbb\">\n")
+	for _, e := range g.Edges() {
+		from, to := layout[e.Source], layout[e.Target]
+		// Directed edges end at the border of the target, so the arrow
+		// stays visible.
+		if target, ok := g.Node(e.Target); ok && e.Directed {
+			distance := math.Hypot(to.X-from.X, to.Y-from.Y)
+			if distance > 0 {
+				r := svgNodeRadius(target)
+				to = point{X: to.X - (to.X-from.X)/distance*r, Y: to.Y - (to.Y-from.Y)/distance*r}
+			}
+		}
+		marker := ""
+		if e.Directed {
+			marker = " marker-end=\"url(#arrow)\""
+		}
+		fmt.Fprintf(&buf, "    <line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke-width=\"%.1f\"%s><title>%s</title></line>\n",
+			from.X, from.Y, to.X, to.Y, 1+math.Log(float64(e.Weight)+1), marker, html.EscapeString(fmt.Sprintf("%s: %s - %s (%d)", e.Kind, e.Source, e.Target, e.Weight)))
+	}
+	buf.WriteString("  </g>\n")
+
+	buf.WriteString("  <g>\n")
+	for _, n := range g.Nodes() {
+		p := layout[n.Name]
+		r := svgNodeRadius(n)
+		title := n.Name
+		if n.MemberCount != nil {
+			title = fmt.Sprintf("%s (%d members)", n.Name, *n.MemberCount)
+		}
+		fmt.Fprintf(&buf, "    <circle cx=\"%.1f\" cy=\"%.1f\" r=\"%.1f\" fill=\"%s\" stroke=\"#ffffff\"><title>%s</title></circle>\n", p.X, p.Y, r, svgNodeColor(n, types), html.EscapeString(title))
+		fmt.Fprintf(&buf, "    <text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%s</text>\n", p.X, p.Y+r+12, html.EscapeString(graphNodeLabel(n.Name)))
+	}
+	buf.WriteString("  </g>\n")
+
+	buf.WriteString("</svg>\n")
+
+	return buf.Bytes()
+}
+
+// encodeSVG lays the graph out in Go and draws it, so an up-to-date
+// picture can be published without Graphviz or a browser.
+func encodeSVG(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	layout := forceLayout(graph, opts.org, opts.imageSize, svgMargin)
+
+	return renderSVG(graph, layout, opts.imageSize, opts.taxonomy), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TeamType maps a team name prefix to a type of team.
+type TeamType struct {
+	Prefix string `yaml:"prefix" json:"prefix"`
+	Key    string `yaml:"key" json:"key"`
+	Label  string `yaml:"label" json:"label"`
+	Color  string `yaml:"color" json:"color"`
+	// Primary types are the teams people belong to day to day, like the
+	// "team-" teams. Membership edges are drawn from teams of primary types
+	// to the other teams their members belong to.
+	Primary bool `yaml:"primary" json:"primary"`
+}
+
+type taxonomy []TeamType
+
+var defaultTaxonomy = taxonomy{
+	{Prefix: "sig-", Key: "sig", Label: "Special interest group", Color: "#1f77b4"},
+	{Prefix: "wg-", Key: "wg", Label: "Working group", Color: "#ff7f0e"},
+	{Prefix: "team-", Key: "team", Label: "Team", Color: "#2ca02c", Primary: true},
+}
+
+// with returns the taxonomy extended by types, replacing existing types
+// with the same key.
+func (t taxonomy) with(types []TeamType) (taxonomy, error) {
+	result := append(taxonomy{}, t...)
+
+	for _, newType := range types {
+		if newType.Prefix == "" || newType.Key == "" {
+			return nil, fmt.Errorf("Team types need a prefix and a key, got prefix '%s' and key '%s'", newType.Prefix, newType.Key)
+		}
+		if strings.Contains(newType.Key, ".") {
+			return nil, fmt.Errorf("Team type key '%s' must not contain '.'", newType.Key)
+		}
+		if newType.Label == "" {
+			newType.Label = newType.Key
+		}
+
+		replaced := false
+		for i := range result {
+			if result[i].Key == newType.Key {
+				result[i] = newType
+				replaced = true
+			}
+		}
+		if !replaced {
+			result = append(result, newType)
+		}
+	}
+
+	return result, nil
+}
+
+func (t taxonomy) prefixes() []string {
+	prefixes := []string{}
+	for _, teamType := range t {
+		prefixes = append(prefixes, teamType.Prefix)
+	}
+	return prefixes
+}
+
+func (t taxonomy) byKey(key string) (TeamType, bool) {
+	for _, teamType := range t {
+		if teamType.Key == key {
+			return teamType, true
+		}
+	}
+	return TeamType{}, false
+}
+
+// typeOf returns the type whose prefix matches the team name, preferring
+// the longest matching prefix.
+func (t taxonomy) typeOf(name string) (TeamType, bool) {
+	lowerName := strings.ToLower(name)
+
+	candidates := append(taxonomy{}, t...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Prefix) > len(candidates[j].Prefix)
+	})
+
+	for _, teamType := range candidates {
+		if strings.HasPrefix(lowerName, strings.ToLower(teamType.Prefix)) {
+			return teamType, true
+		}
+	}
+
+	return TeamType{}, false
+}
+
+func (t taxonomy) graphTeamName(org, name string) (string, TeamType, error) {
+	teamType, ok := t.typeOf(name)
+	if !ok {
+		return "", TeamType{}, fmt.Errorf("Unknown team name prefix for team '%s'", name)
+	}
+
+	return fmt.Sprintf("%s.%s.%s", org, teamType.Key, strings.ReplaceAll(name, " ", "")), teamType, nil
+}
+
+func encodeTypes(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	return marshalIndented(opts.taxonomy)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadTeamList reads a file with one team slug per line. Everything after a
+// '#' is a comment, blank lines are ignored.
+func loadTeamList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening team list '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	slugs := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line != "" && !contains(slugs, line) {
+			slugs = append(slugs, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading team list '%s': %w", path, err)
+	}
+
+	return slugs, nil
+}
+
+// loadTeamLists reads the allowlist and denylist files of the filter.
+func (f *teamFilter) loadTeamLists() error {
+	var err error
+	f.allow, f.deny = nil, nil
+
+	if f.Allowlist != "" {
+		f.allow, err = loadTeamList(f.Allowlist)
+		if err != nil {
+			return err
+		}
+	}
+	if f.Denylist != "" {
+		f.deny, err = loadTeamList(f.Denylist)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
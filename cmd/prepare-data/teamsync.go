@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+type IdPGroup struct {
+	ID          string `json:"group_id"`
+	Name        string `json:"group_name"`
+	Description string `json:"group_description"`
+}
+
+func fetchTeamSyncGroups(ctx context.Context, org, slug string) ([]IdPGroup, error) {
+	progress.Printf("fetching team sync group mappings for '%s'\n", slug)
+	groupBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams/%s/team-sync/group-mappings", github.APIURL, org, slug))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching group mappings for slug %s: %w", slug, err)
+	}
+
+	var response struct {
+		Groups []IdPGroup `json:"groups"`
+	}
+
+	err = json.Unmarshal(groupBytes, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing group mappings for slug %s: %w", slug, err)
+	}
+
+	return response.Groups, nil
+}
+
+// attachTeamSync records the identity provider groups each team is
+// synchronized with. Teams without mappings get an empty list, teams whose
+// mappings can't be read are left without the attribute.
+func attachTeamSync(ctx context.Context, org string, teams []Team) {
+	for i := range teams {
+		groups, err := fetchTeamSyncGroups(ctx, org, teams[i].Slug)
+		if err != nil {
+			log.Printf("skipping team sync for '%s': %v\n", teams[i].Slug, err)
+			continue
+		}
+
+		names := []string{}
+		for _, group := range groups {
+			names = append(names, group.Name)
+		}
+		teams[i].IdPGroups = &names
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateData is what user supplied templates are executed with.
+type templateData struct {
+	Org         string
+	GeneratedAt time.Time
+	Teams       []Team
+	Graph       []Node
+	Edges       []Edge
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func parseTemplateFile(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing template '%s': %w", path, err)
+	}
+	return tmpl, nil
+}
+
+func encodeTemplate(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.templatePath == "" {
+		return nil, fmt.Errorf("The template format requires --template")
+	}
+
+	tmpl, err := parseTemplateFile(opts.templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := toGraph(teams, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating graph: %w", err)
+	}
+
+	if opts.privacyMode {
+		anonymous := []Team{}
+		for _, team := range teams {
+			team.Members = nil
+			team.Maintainers = nil
+			anonymous = append(anonymous, team)
+		}
+		teams = anonymous
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateData{Org: opts.org, GeneratedAt: time.Now().UTC(), Teams: teams, Graph: graph.Nodes(), Edges: graph.Edges()})
+	if err != nil {
+		return nil, fmt.Errorf("Error executing template '%s': %w", opts.templatePath, err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+const turtleFormat = "turtle"
+
+// turtleOntology builds on the W3C organization ontology: teams are
+// organizational units of the org and members are people who are members
+// of them. A few terms are added for what is specific to GitHub teams.
+const turtleOntology = `@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+@prefix foaf: <http://xmlns.com/foaf/0.1/> .
+@prefix org: <http://www.w3.org/ns/org#> .
+@prefix ov: <https://github.com/giantswarm/org-vis/ontology#> .
+
+ov:Team a rdfs:Class ;
+  rdfs:subClassOf org:OrganizationalUnit ;
+  rdfs:label "GitHub team" .
+
+ov:maintainerOf a rdf:Property ;
+  rdfs:subPropertyOf org:memberOf ;
+  rdfs:label "maintainer of" .
+
+ov:teamType a rdf:Property ;
+  rdfs:domain ov:Team ;
+  rdfs:label "team type" .
+
+ov:privacy a rdf:Property ;
+  rdfs:domain ov:Team ;
+  rdfs:label "privacy" .
+
+`
+
+// turtleString quotes value as a Turtle string literal.
+func turtleString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(value) + `"`
+}
+
+// encodeTurtle writes the teams, their members and the memberships as RDF
+// in Turtle. Teams, people and the org are identified by their GitHub URLs.
+func encodeTurtle(ctx context.Context, teams []Team, opts *options) ([]byte, error) {
+	if opts.privacyMode {
+		return nil, fmt.Errorf("The %s format lists members and can't be used in privacy mode", turtleFormat)
+	}
+
+	orgIRI := fmt.Sprintf("<%s/%s>", github.WebURL(), url.PathEscape(opts.org))
+	teamIRI := func(slug string) string {
+		return fmt.Sprintf("<%s/orgs/%s/teams/%s>", github.WebURL(), url.PathEscape(opts.org), url.PathEscape(slug))
+	}
+	personIRI := func(login string) string {
+		return fmt.Sprintf("<%s/%s>", github.WebURL(), url.PathEscape(login))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(turtleOntology)
+
+	fmt.Fprintf(&buf, "%s a org:FormalOrganization ;\n  rdfs:label %s .\n", orgIRI, turtleString(opts.org))
+
+	for _, team := range teams {
+		fmt.Fprintf(&buf, "\n%s a ov:Team ;\n  rdfs:label %s ;\n  org:unitOf %s", teamIRI(team.Slug), turtleString(team.Name), orgIRI)
+		if teamType, ok := opts.taxonomy.typeOf(team.Name); ok {
+			fmt.Fprintf(&buf, " ;\n  ov:teamType %s", turtleString(teamType.Key))
+		}
+		if team.Privacy != "" {
+			fmt.Fprintf(&buf, " ;\n  ov:privacy %s", turtleString(team.Privacy))
+		}
+		if team.Parent != nil {
+			fmt.Fprintf(&buf, " ;\n  org:subOrganizationOf %s", teamIRI(team.Parent.Slug))
+		}
+		buf.WriteString(" .\n")
+	}
+
+	memberOf := map[string][]string{}
+	maintainerOf := map[string][]string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if memberRole(team, member) == roleMaintainer {
+				maintainerOf[member] = append(maintainerOf[member], teamIRI(team.Slug))
+			} else {
+				memberOf[member] = append(memberOf[member], teamIRI(team.Slug))
+			}
+		}
+	}
+
+	logins := []string{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			if !contains(logins, member) {
+				logins = append(logins, member)
+			}
+		}
+	}
+	sort.Strings(logins)
+
+	for _, login := range logins {
+		fmt.Fprintf(&buf, "\n%s a foaf:Person ;\n  foaf:nick %s", personIRI(login), turtleString(login))
+		if len(maintainerOf[login]) > 0 {
+			fmt.Fprintf(&buf, " ;\n  ov:maintainerOf %s", strings.Join(maintainerOf[login], ", "))
+		}
+		if len(memberOf[login]) > 0 {
+			fmt.Fprintf(&buf, " ;\n  org:memberOf %s", strings.Join(memberOf[login], ", "))
+		}
+		buf.WriteString(" .\n")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+)
+
+// runWarm fetches everything a run with the same flags would, renewing
+// every cache entry, but writes no outputs. Later runs with --cache-ttl
+// are then served from the cache.
+func runWarm(ctx context.Context, args []string) error {
+	base, err := parseOptions(flag.NewFlagSet("warm", flag.ExitOnError), args)
+	if err != nil {
+		return err
+	}
+
+	if base.quiet {
+		progress.SetOutput(io.Discard)
+	}
+
+	opts, err := resolveOptions(base)
+	if err != nil {
+		return err
+	}
+
+	cache = &responseCache{dir: opts.cacheDir, refresh: true}
+
+	teams, err := collectTeams(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	progress.Printf("warmed cache in %s with %d teams\n", opts.cacheDir, len(teams))
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watch polls the organization every interval and publishes the teams
+// whenever the fetched teams differ from the previous poll. Failed polls
+// are logged and retried on the next tick.
+//
+// On SIGHUP, or when the config file changes and watchConfig is set, the
+// config file is re-read on top of base and the organization
+// is polled right away with the new settings. The teams of the previous
+// poll are kept, so the first poll after a reload reports what changed.
+func watch(ctx context.Context, base *options, opts *options, publish func(ctx context.Context, teams []Team, opts *options) error) error {
+	if opts.interval <= 0 {
+		return fmt.Errorf("Interval must be positive, got %s", opts.interval)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var configChanged <-chan struct{}
+	if opts.watchConfig && base.configPath != "" {
+		changed, stop, err := watchConfigFile(base.configPath)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		configChanged = changed
+	}
+
+	// opts is replaced on reloads, so the store of the current one is
+	// closed.
+	defer func() { closeSnapshotStore(opts) }()
+
+	var previous []Team
+	// optionsChanged makes the next poll publish even when no team changed,
+	// as the outputs depend on the options, too.
+	optionsChanged := false
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for {
+		pollCtx := ctx
+		cancel := func() {}
+		if opts.timeout > 0 {
+			pollCtx, cancel = context.WithTimeout(ctx, opts.timeout)
+		}
+		teams, err := collectTeams(pollCtx, opts)
+		cancel()
+		if ctx.Err() != nil {
+			log.Printf("stopping: %v\n", ctx.Err())
+			return nil
+		}
+		if err != nil {
+			log.Printf("%v\n", err)
+		} else {
+			changes := diffTeams(previous, teams, detectRenames(previous, teams))
+			if previous == nil || optionsChanged || len(changes) > 0 {
+				// On stderr, stdout may carry an output or the summary.
+				for _, change := range changes {
+					progress.Println(change)
+				}
+
+				if previous != nil {
+					err = opts.alerts.notify(ctx, opts.org, opts.alerts.check(previous, teams))
+					if err != nil {
+						log.Printf("%v\n", err)
+					}
+				}
+
+				err = publish(ctx, teams, opts)
+				if err != nil {
+					log.Printf("%v\n", err)
+				} else {
+					previous = teams
+					optionsChanged = false
+				}
+			} else {
+				progress.Println("no changes")
+			}
+		}
+
+		logRateLimits()
+		progress.Printf("next refresh in %s\n", opts.interval)
+
+	wait:
+		for {
+			select {
+			case <-ticker.C:
+				break wait
+			case <-ctx.Done():
+				log.Printf("stopping: %v\n", ctx.Err())
+				return nil
+			case <-hup:
+			case <-configChanged:
+			}
+
+			reloaded, err := resolveOptions(base)
+			if err == nil && reloaded.interval <= 0 {
+				err = fmt.Errorf("Interval must be positive, got %s", reloaded.interval)
+			}
+			if err != nil {
+				log.Printf("Error reloading config, keeping the current one: %v\n", err)
+				continue
+			}
+
+			// The reloaded options are adopted in any case, the changes are
+			// only described for the log.
+			changes := describeOptionChanges(opts, reloaded)
+			// Keep using the open snapshot store if it didn't move, the one
+			// that is replaced is closed.
+			if reloaded.snapshotDir == opts.snapshotDir && reloaded.perms == opts.perms {
+				closeSnapshotStore(reloaded)
+				reloaded.snapshots = opts.snapshots
+			} else {
+				closeSnapshotStore(opts)
+			}
+			opts = reloaded
+			optionsChanged = true
+			if len(changes) == 0 {
+				log.Printf("config in %s is unchanged\n", base.configPath)
+				continue
+			}
+
+			log.Printf("reloaded config from %s\n", base.configPath)
+			for _, change := range changes {
+				log.Printf("  %s\n", change)
+			}
+
+			ticker.Reset(opts.interval)
+			break wait
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WhatIfChange is one hypothetical change of a what-if overlay. Exactly one
+// of Move, Add, Remove, Merge or Create is set.
+type WhatIfChange struct {
+	// Move moves a person from the team From, or from all their teams if
+	// empty, to the team To.
+	Move string `yaml:"move"`
+	// Add adds a person to the team To.
+	Add string `yaml:"add"`
+	// Remove removes a person from the team From, or from all teams.
+	Remove string `yaml:"remove"`
+	From   string `yaml:"from"`
+	To     string `yaml:"to"`
+
+	// Merge merges teams into the team named Into, by default the first.
+	Merge []string `yaml:"merge"`
+	Into  string   `yaml:"into"`
+
+	// Create adds a new team with Members and the optional Parent.
+	Create  string   `yaml:"create"`
+	Members []string `yaml:"members"`
+	Parent  string   `yaml:"parent"`
+}
+
+type whatIfOverlay struct {
+	Changes []WhatIfChange `yaml:"changes"`
+}
+
+func loadWhatIfOverlay(path string) (*whatIfOverlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading what-if overlay: %w", err)
+	}
+
+	var overlay whatIfOverlay
+	err = yaml.Unmarshal(data, &overlay)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing what-if overlay '%s': %w", path, err)
+	}
+
+	for i, change := range overlay.Changes {
+		set := 0
+		for _, value := range []string{change.Move, change.Add, change.Remove, change.Create} {
+			if value != "" {
+				set++
+			}
+		}
+		if len(change.Merge) > 0 {
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("Change %d of what-if overlay '%s' must set exactly one of move, add, remove, merge or create", i+1, path)
+		}
+		if (change.Move != "" || change.Add != "") && change.To == "" {
+			return nil, fmt.Errorf("Change %d of what-if overlay '%s' needs a team to move or add to", i+1, path)
+		}
+		if len(change.Merge) == 1 {
+			return nil, fmt.Errorf("Change %d of what-if overlay '%s' needs at least two teams to merge", i+1, path)
+		}
+	}
+
+	return &overlay, nil
+}
+
+func whatIfTeam(teams []Team, name string) (int, error) {
+	for i, team := range teams {
+		if strings.EqualFold(team.Name, name) || strings.EqualFold(team.Slug, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown team '%s' in what-if overlay", name)
+}
+
+func withoutMember(members []string, login string) []string {
+	remaining := []string{}
+	for _, member := range members {
+		if !strings.EqualFold(member, login) {
+			remaining = append(remaining, member)
+		}
+	}
+	return remaining
+}
+
+func removeFromTeams(teams []Team, login, from string) error {
+	if from == "" {
+		for i := range teams {
+			teams[i].Members = withoutMember(teams[i].Members, login)
+			teams[i].Maintainers = withoutMember(teams[i].Maintainers, login)
+		}
+		return nil
+	}
+
+	i, err := whatIfTeam(teams, from)
+	if err != nil {
+		return err
+	}
+	teams[i].Members = withoutMember(teams[i].Members, login)
+	teams[i].Maintainers = withoutMember(teams[i].Maintainers, login)
+	return nil
+}
+
+func addToTeam(teams []Team, login, to string) error {
+	i, err := whatIfTeam(teams, to)
+	if err != nil {
+		return err
+	}
+	teams[i].Members = union(teams[i].Members, []string{login})
+	return nil
+}
+
+func union(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, value := range b {
+		if !contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func mergeTeams(teams []Team, names []string, into string) ([]Team, error) {
+	indices := []int{}
+	for _, name := range names {
+		i, err := whatIfTeam(teams, name)
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, i)
+	}
+	return mergeTeamsAt(teams, indices, into), nil
+}
+
+// mergeTeamsAt merges the teams at indices into the first of them, named
+// into if it isn't empty.
+func mergeTeamsAt(teams []Team, indices []int, into string) []Team {
+	target := indices[0]
+	merged := teams[target]
+	oldSlugs := []string{merged.Slug}
+
+	for _, i := range indices[1:] {
+		team := teams[i]
+		merged.Members = union(merged.Members, team.Members)
+		merged.Maintainers = union(merged.Maintainers, team.Maintainers)
+		merged.Repos = union(merged.Repos, team.Repos)
+		merged.Projects = union(merged.Projects, team.Projects)
+		merged.OnCall = union(merged.OnCall, team.OnCall)
+		oldSlugs = append(oldSlugs, team.Slug)
+	}
+	if into != "" {
+		merged.Name = into
+		merged.Slug = teamSlug(into)
+	}
+
+	if merged.Parent != nil && contains(oldSlugs, merged.Parent.Slug) {
+		merged.Parent = nil
+	}
+
+	result := []Team{}
+	for i, team := range teams {
+		if i == target {
+			result = append(result, merged)
+			continue
+		}
+		if contains(oldSlugs, team.Slug) {
+			continue
+		}
+		if team.Parent != nil && contains(oldSlugs, team.Parent.Slug) {
+			team.Parent = &TeamRef{Name: merged.Name, Slug: merged.Slug}
+		}
+		result = append(result, team)
+	}
+
+	return result
+}
+
+// apply returns the teams with the hypothetical changes of the overlay
+// applied in order.
+func (o *whatIfOverlay) apply(teams []Team) ([]Team, error) {
+	teams = append([]Team{}, teams...)
+
+	for _, change := range o.Changes {
+		var err error
+		switch {
+		case change.Move != "":
+			err = removeFromTeams(teams, change.Move, change.From)
+			if err == nil {
+				err = addToTeam(teams, change.Move, change.To)
+			}
+		case change.Add != "":
+			err = addToTeam(teams, change.Add, change.To)
+		case change.Remove != "":
+			err = removeFromTeams(teams, change.Remove, change.From)
+		case len(change.Merge) > 0:
+			teams, err = mergeTeams(teams, change.Merge, change.Into)
+		case change.Create != "":
+			team := Team{Name: change.Create, Slug: teamSlug(change.Create), Privacy: privacyClosed, Members: union(nil, change.Members)}
+			if change.Parent != "" {
+				var i int
+				i, err = whatIfTeam(teams, change.Parent)
+				if err == nil {
+					team.Parent = &TeamRef{Name: teams[i].Name, Slug: teams[i].Slug}
+				}
+			}
+			teams = append(teams, team)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return teams, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/giantswarm/org-vis/pkg/github"
+)
+
+type MemberTeam struct {
+	Team string `json:"team"`
+	Role string `json:"role"`
+}
+
+// Collaborator is someone sharing at least one team with the looked up
+// member.
+type Collaborator struct {
+	Login string   `json:"login"`
+	Teams []string `json:"teams"`
+}
+
+type MemberReport struct {
+	Login         string         `json:"login"`
+	Teams         []MemberTeam   `json:"teams"`
+	Collaborators []Collaborator `json:"collaborators"`
+}
+
+func fetchTeamRole(ctx context.Context, org, slug, login string) (string, error) {
+	membershipBytes, err := fetchJSON(ctx, fmt.Sprintf("%s/orgs/%s/teams/%s/memberships/%s", github.APIURL, org, slug, login))
+	if err != nil {
+		return "", fmt.Errorf("Error fetching membership of %s in %s: %w", login, slug, err)
+	}
+
+	var membership struct {
+		Role string `json:"role"`
+	}
+
+	err = json.Unmarshal(membershipBytes, &membership)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing membership of %s in %s: %w", login, slug, err)
+	}
+
+	return membership.Role, nil
+}
+
+func memberLogin(team Team, login string) (string, bool) {
+	for _, member := range team.Members {
+		if strings.EqualFold(member, login) {
+			return member, true
+		}
+	}
+	return "", false
+}
+
+// memberReport lists the teams login belongs to and the people sharing the
+// most teams with them. role looks up the role of login in a team.
+func memberReport(teams []Team, login string, maxCollaborators int, role func(team Team) (string, error)) (MemberReport, error) {
+	report := MemberReport{Login: login, Teams: []MemberTeam{}, Collaborators: []Collaborator{}}
+	shared := map[string][]string{}
+
+	for _, team := range teams {
+		actual, ok := memberLogin(team, login)
+		if !ok {
+			continue
+		}
+		report.Login = actual
+
+		teamRole, err := role(team)
+		if err != nil {
+			return report, err
+		}
+		report.Teams = append(report.Teams, MemberTeam{Team: team.Name, Role: teamRole})
+
+		for _, member := range team.Members {
+			if member != actual {
+				shared[member] = append(shared[member], team.Name)
+			}
+		}
+	}
+
+	for member, memberTeams := range shared {
+		report.Collaborators = append(report.Collaborators, Collaborator{Login: member, Teams: memberTeams})
+	}
+	sort.Slice(report.Collaborators, func(i, j int) bool {
+		a, b := report.Collaborators[i], report.Collaborators[j]
+		if len(a.Teams) != len(b.Teams) {
+			return len(a.Teams) > len(b.Teams)
+		}
+		return a.Login < b.Login
+	})
+	if maxCollaborators >= 0 && len(report.Collaborators) > maxCollaborators {
+		report.Collaborators = report.Collaborators[:maxCollaborators]
+	}
+
+	return report, nil
+}
+
+func printMemberReport(report MemberReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, report.Login)
+	for _, team := range report.Teams {
+		fmt.Fprintf(w, "  %s\t%s\n", team.Team, team.Role)
+	}
+
+	if len(report.Collaborators) > 0 {
+		fmt.Fprintln(w, "\nnearest collaborators")
+		for _, c := range report.Collaborators {
+			fmt.Fprintf(w, "  %s\t%d\t%s\n", c.Login, len(c.Teams), strings.Join(c.Teams, ", "))
+		}
+	}
+
+	w.Flush()
+}
+
+func runWho(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("who", flag.ExitOnError)
+	org := flags.String("org", orgFromEnv(), "organization to look up teams in")
+	allTeams := flags.Bool("all-teams", false, "include teams that don't follow the sig-/team-/wg- naming convention")
+	collaborators := flags.Int("collaborators", 5, "number of nearest collaborators to list, -1 for all")
+	jsonOutput := flags.Bool("json", false, "print the report as json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s who [flags] <login>\n", flags.Name())
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("Expected one login, got %d", flags.NArg())
+	}
+	login := flags.Arg(0)
+
+	var teams []Team
+	var err error
+	if *allTeams {
+		teams, err = fetchAllTeamsWithMembers(ctx, *org)
+	} else {
+		teams, err = fetchTeams(ctx, *org, defaultTeamFilter)
+	}
+	if err != nil {
+		return fmt.Errorf("Error fetching teams of %s: %w", *org, err)
+	}
+
+	report, err := memberReport(teams, login, *collaborators, func(team Team) (string, error) {
+		return fetchTeamRole(ctx, *org, team.Slug, login)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(report.Teams) == 0 {
+		return fmt.Errorf("%s is not a member of any team in %s", login, *org)
+	}
+
+	if *jsonOutput {
+		data, err := marshalIndented(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printMemberReport(report)
+
+	return nil
+}
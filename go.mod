@@ -1,3 +1,14 @@
 module github.com/giantswarm/org-vis
 
-go 1.16
+go 1.17
+
+require (
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/jmespath/go-jmespath v0.4.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	golang.org/x/image v0.5.0
+	golang.org/x/sync v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.0.0-20220908164124-27713097b956 // indirect
@@ -0,0 +1,225 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// app authenticates requests as a GitHub App installation. Installation
+// tokens are valid for an hour and refreshed shortly before they expire.
+type app struct {
+	id             string
+	installationID string
+	key            *rsa.PrivateKey
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+var (
+	appOnce sync.Once
+	appAuth *app
+	appErr  error
+)
+
+// appFromEnv reads the app credentials from GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY (the PEM key) or
+// GITHUB_APP_PRIVATE_KEY_PATH. It returns nil when GITHUB_APP_ID is unset.
+func appFromEnv() (*app, error) {
+	id := os.Getenv("GITHUB_APP_ID")
+	if id == "" {
+		return nil, nil
+	}
+	installationID := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	if installationID == "" {
+		return nil, fmt.Errorf("GITHUB_APP_INSTALLATION_ID must be set with GITHUB_APP_ID")
+	}
+	for _, value := range []string{id, installationID} {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("Invalid GitHub App id '%s': %w", value, err)
+		}
+	}
+
+	keyPEM := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if path := os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"); len(keyPEM) == 0 && path != "" {
+		var err error
+		keyPEM, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading GitHub App private key: %w", err)
+		}
+	}
+	if len(keyPEM) == 0 {
+		return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH must be set with GITHUB_APP_ID")
+	}
+
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{id: id, installationID: installationID, key: key}, nil
+}
+
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("Error parsing GitHub App private key: no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Error parsing GitHub App private key: not an RSA key")
+	}
+
+	return key, nil
+}
+
+// jwt returns the token authenticating as the app itself, which is only
+// used to create installation tokens.
+func (a *app) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// iat is backdated to allow for clock drift, exp is at most 10 minutes.
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("Error signing GitHub App token: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (a *app) installationToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if a.token != "" && now.Add(5*time.Minute).Before(a.expires) {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt(now)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", APIURL, a.installationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(nil))
+	if err != nil {
+		return "", fmt.Errorf("Error constructing request for url '%s': %w", url, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, body, err := do(req)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkResponse(url, resp, body)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing installation token: %w", err)
+	}
+
+	a.token = token.Token
+	a.expires = token.ExpiresAt
+
+	return a.token, nil
+}
+
+// Anonymous reports whether neither GITHUB_TOKEN nor GITHUB_APP_ID is set.
+// Requests are sent unauthenticated then, which GitHub only answers with
+// public data and allows 60 of per hour.
+func Anonymous() bool {
+	return os.Getenv("GITHUB_APP_ID") == "" && os.Getenv("GITHUB_TOKEN") == ""
+}
+
+// authorization returns the Authorization header of API requests, using the
+// credentials of ctx if any, the GitHub App installation if configured and
+// GITHUB_TOKEN otherwise. It is empty for anonymous requests.
+func authorization(ctx context.Context) (string, error) {
+	if credentials, ok := credentialsFrom(ctx); ok {
+		return credentials.authorization(ctx)
+	}
+	appOnce.Do(func() {
+		appAuth, appErr = appFromEnv()
+	})
+	if appErr != nil {
+		return "", appErr
+	}
+	if appAuth == nil {
+		if Anonymous() {
+			return "", nil
+		}
+		return "token " + os.Getenv("GITHUB_TOKEN"), nil
+	}
+
+	token, err := appAuth.installationToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Error creating GitHub App installation token: %w", err)
+	}
+
+	return "token " + token, nil
+}
+
+// Credentials resolves the credentials requests are authenticated with,
+// creating an installation token for GitHub Apps, and describes them.
+func Credentials(ctx context.Context) (string, error) {
+	_, err := authorization(ctx)
+	if err != nil {
+		return "", err
+	}
+	if credentials, ok := credentialsFrom(ctx); ok {
+		return credentials.String(), nil
+	}
+	if appAuth != nil {
+		return fmt.Sprintf("GitHub App %s, installation %s", appAuth.id, appAuth.installationID), nil
+	}
+	if Anonymous() {
+		return "no credentials, neither GITHUB_TOKEN nor GITHUB_APP_ID is set", nil
+	}
+	return "GITHUB_TOKEN", nil
+}
@@ -0,0 +1,21 @@
+package github
+
+// CachedResponse is a response stored to revalidate it with a conditional
+// request. Link is kept so that pagination keeps working on 304 responses.
+type CachedResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	Link         string
+}
+
+// Cache stores REST responses by URL. When set as ResponseCache, requests
+// send the stored validators and a 304 response, which doesn't count
+// against the rate limit, returns the stored body.
+type Cache interface {
+	Get(url string) (CachedResponse, bool)
+	Put(url string, response CachedResponse)
+}
+
+// ResponseCache enables conditional requests when set.
+var ResponseCache Cache
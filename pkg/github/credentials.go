@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextCredentials replace the credentials from the environment for the
+// requests made with a context, see WithToken and WithApp.
+type contextCredentials struct {
+	token string
+	app   *app
+}
+
+type credentialsKey struct{}
+
+// WithToken returns a context whose requests are authenticated with token
+// instead of the credentials from the environment, e.g. to fetch several
+// organizations that no single token has access to.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, &contextCredentials{token: token})
+}
+
+// WithApp returns a context whose requests are authenticated as the
+// installation of a GitHub App, like GITHUB_APP_ID does for all requests.
+// Installation tokens are shared by requests with the returned context.
+func WithApp(ctx context.Context, id, installationID string, keyPEM []byte) (context.Context, error) {
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	a := &app{id: id, installationID: installationID, key: key}
+	return context.WithValue(ctx, credentialsKey{}, &contextCredentials{app: a}), nil
+}
+
+func credentialsFrom(ctx context.Context) (*contextCredentials, bool) {
+	credentials, ok := ctx.Value(credentialsKey{}).(*contextCredentials)
+	return credentials, ok
+}
+
+// authorization returns the Authorization header of the credentials.
+func (c *contextCredentials) authorization(ctx context.Context) (string, error) {
+	if c.app == nil {
+		return "token " + c.token, nil
+	}
+	token, err := c.app.installationToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Error creating GitHub App installation token: %w", err)
+	}
+	return "token " + token, nil
+}
+
+func (c *contextCredentials) String() string {
+	if c.app == nil {
+		return "token"
+	}
+	return fmt.Sprintf("GitHub App %s, installation %s", c.app.id, c.app.installationID)
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuthError is returned when GitHub rejects the token, or the token lacks
+// the scopes needed for a request. MissingScopes lists the scopes of which
+// the token needs at least one, if GitHub told us.
+type AuthError struct {
+	URL              string
+	StatusCode       int
+	Message          string
+	MissingScopes    []string
+	DocumentationURL string
+}
+
+func (e *AuthError) Error() string {
+	hint := ""
+	switch {
+	case len(e.MissingScopes) > 0:
+		hint = fmt.Sprintf(", the token needs one of the scopes %s", strings.Join(e.MissingScopes, ", "))
+	case Anonymous():
+		hint = ", anonymous requests only see public data, set GITHUB_TOKEN"
+	case e.StatusCode == 401:
+		hint = ", check that GITHUB_TOKEN is set and valid"
+	}
+	return fmt.Sprintf("Authentication failed for url '%s' (%d): %s%s%s", e.URL, e.StatusCode, e.Message, hint, documentation(e.DocumentationURL))
+}
+
+// RateLimitError is returned when the primary or a secondary rate limit was
+// hit. Reset is when requests are accepted again, if GitHub told us.
+type RateLimitError struct {
+	URL              string
+	Reset            time.Time
+	Message          string
+	DocumentationURL string
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("Rate limit exceeded for url '%s': %s%s", e.URL, e.Message, documentation(e.DocumentationURL))
+	}
+	return fmt.Sprintf("Rate limit exceeded for url '%s' until %s: %s%s", e.URL, e.Reset.Format(time.RFC3339), e.Message, documentation(e.DocumentationURL))
+}
+
+// NotFoundError is returned for 404 responses. GitHub also answers with 404
+// for resources the token may not see.
+type NotFoundError struct {
+	URL              string
+	DocumentationURL string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Not found: url '%s', it doesn't exist or the token can't see it%s", e.URL, documentation(e.DocumentationURL))
+}
+
+// QueryError is returned when GraphQL reports errors for a query, such as
+// MAX_NODE_LIMIT_EXCEEDED when it would return too many nodes. Types are
+// empty for errors without a type.
+type QueryError struct {
+	Types    []string
+	Messages []string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("GraphQL query failed: %s", strings.Join(e.Messages, "; "))
+}
+
+// HasType reports whether any of the errors has the type.
+func (e *QueryError) HasType(errorType string) bool {
+	for _, t := range e.Types {
+		if t == errorType {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusError is returned for any other unsuccessful response.
+type StatusError struct {
+	URL              string
+	StatusCode       int
+	Message          string
+	DocumentationURL string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Unexpected status %d for url '%s': %s%s", e.StatusCode, e.URL, e.Message, documentation(e.DocumentationURL))
+}
+
+func documentation(url string) string {
+	if url == "" {
+		return ""
+	}
+	return " (see " + url + ")"
+}
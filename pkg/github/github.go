@@ -0,0 +1,205 @@
+// Package github is a small client for the GitHub REST and GraphQL APIs of
+// github.com or, with APIURL, a GitHub Enterprise Server instance.
+// Requests are authenticated with the GITHUB_TOKEN environment variable, or
+// as a GitHub App installation when GITHUB_APP_ID is set. Without either,
+// REST requests are sent anonymously.
+// Unsuccessful responses are returned as *AuthError, *RateLimitError,
+// *NotFoundError, *QueryError or *StatusError and can be told apart with
+// errors.As.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PublicAPIURL is the REST API of github.com.
+const PublicAPIURL = "https://api.github.com"
+
+// APIURL is the base URL of the REST API, without a trailing slash. For
+// GitHub Enterprise Server it is https://<host>/api/v3. Only requests to
+// APIURL are authenticated, so credentials of an Enterprise Server
+// instance aren't sent to github.com.
+var APIURL = PublicAPIURL
+
+// Client sends all requests. It can be replaced to use a proxy, custom CA
+// certificates or client certificates.
+var Client = http.DefaultClient
+
+// WebURL returns the URL of the web interface of the APIURL instance, like
+// https://github.com.
+func WebURL() string {
+	if APIURL == PublicAPIURL {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(APIURL, "/api/v3")
+}
+
+// Get fetches url from the REST API and returns the response body.
+func Get(ctx context.Context, url string) ([]byte, error) {
+	body, _, err := get(ctx, url)
+	return body, err
+}
+
+// GetAll fetches a list endpoint and follows the rel="next" links of the
+// Link header, returning the items of all pages as a single JSON array.
+func GetAll(ctx context.Context, url string) ([]byte, error) {
+	items := []json.RawMessage{}
+
+	for url != "" {
+		body, header, err := get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []json.RawMessage
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing page of url '%s': %w", url, err)
+		}
+		items = append(items, page...)
+
+		url = nextPage(header)
+	}
+
+	return json.Marshal(items)
+}
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+
+func nextPage(header http.Header) string {
+	match := nextLinkPattern.FindStringSubmatch(header.Get("Link"))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func get(ctx context.Context, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error constructing request for url '%s': %w", url, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if strings.HasPrefix(url, APIURL+"/") {
+		auth, err := authorization(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	}
+
+	var cached CachedResponse
+	var ok bool
+	if ResponseCache != nil {
+		cached, ok = ResponseCache.Get(url)
+		if ok && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if ok && cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, body, err := do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		header := resp.Header.Clone()
+		header.Set("Link", cached.Link)
+		return cached.Body, header, nil
+	}
+
+	err = checkResponse(url, resp, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ResponseCache != nil && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		ResponseCache.Put(url, CachedResponse{
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Link:         resp.Header.Get("Link"),
+		})
+	}
+
+	return body, resp.Header, nil
+}
+
+func checkResponse(url string, resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var errorBody struct {
+		Message          string `json:"message"`
+		DocumentationURL string `json:"documentation_url"`
+	}
+	_ = json.Unmarshal(body, &errorBody)
+	message := errorBody.Message
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	docs := errorBody.DocumentationURL
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && (resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""):
+		return &RateLimitError{URL: url, Reset: rateLimitReset(resp.Header), Message: message, DocumentationURL: docs}
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return &AuthError{URL: url, StatusCode: resp.StatusCode, Message: message, MissingScopes: missingScopes(resp.Header), DocumentationURL: docs}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{URL: url, DocumentationURL: docs}
+	default:
+		return &StatusError{URL: url, StatusCode: resp.StatusCode, Message: message, DocumentationURL: docs}
+	}
+}
+
+// missingScopes returns the scopes accepted for a request when the token
+// has none of them. Only classic tokens report their scopes.
+func missingScopes(header http.Header) []string {
+	accepted := scopeList(header.Get("X-Accepted-OAuth-Scopes"))
+	if len(accepted) == 0 || len(header.Values("X-OAuth-Scopes")) == 0 {
+		return nil
+	}
+	granted := scopeList(header.Get("X-OAuth-Scopes"))
+	for _, scope := range accepted {
+		for _, grantedScope := range granted {
+			if scope == grantedScope {
+				return nil
+			}
+		}
+	}
+	return accepted
+}
+
+func scopeList(value string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func rateLimitReset(header http.Header) time.Time {
+	if retryAfter, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(retryAfter) * time.Second)
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	return time.Time{}
+}
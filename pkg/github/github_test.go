@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// pagedServer serves /items in pages of one item each, linking every page
+// but the last to the next one.
+func pagedServer(t *testing.T, pages int, handle func(w http.ResponseWriter, r *http.Request, page int) bool) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 1
+		}
+		if handle != nil && handle(w, r, page) {
+			return
+		}
+		if page < pages {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=%d>; rel="next", <%s/items?page=%d>; rel="last"`, srv.URL, page+1, srv.URL, pages))
+		}
+		fmt.Fprintf(w, "[%d]", page)
+	}))
+	t.Cleanup(srv.Close)
+
+	previous := APIURL
+	APIURL = srv.URL
+	t.Cleanup(func() { APIURL = previous })
+	t.Setenv("GITHUB_TOKEN", "test")
+
+	return srv
+}
+
+func TestGetAllFollowsNextLinks(t *testing.T) {
+	requests := 0
+	srv := pagedServer(t, 3, func(w http.ResponseWriter, r *http.Request, page int) bool {
+		requests++
+		return false
+	})
+
+	body, err := GetAll(context.Background(), srv.URL+"/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != "[1,2,3]" {
+		t.Errorf("expected the items of all pages, got %s", body)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+}
+
+func TestGetAllStopsWithoutNextLink(t *testing.T) {
+	cases := map[string]string{
+		"missing":    "",
+		"only prev":  `<http://example.com/items?page=1>; rel="prev"`,
+		"no bracket": `http://example.com/items?page=2; rel="next"`,
+		"empty":      " , ",
+	}
+	for name, link := range cases {
+		link := link
+		t.Run(name, func(t *testing.T) {
+			srv := pagedServer(t, 1, func(w http.ResponseWriter, r *http.Request, page int) bool {
+				if page > 1 {
+					t.Errorf("unexpected request for page %d", page)
+				}
+				if link != "" {
+					w.Header().Set("Link", link)
+				}
+				fmt.Fprint(w, "[1]")
+				return true
+			})
+
+			body, err := GetAll(context.Background(), srv.URL+"/items")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "[1]" {
+				t.Errorf("expected a single page, got %s", body)
+			}
+		})
+	}
+}
+
+func TestNextPageWithSpacingAndOrder(t *testing.T) {
+	header := http.Header{}
+	header.Set("Link", `<https://api.github.com/items?page=1>; rel="first" ,<https://api.github.com/items?page=3>;rel="next"`)
+
+	if next := nextPage(header); next != "https://api.github.com/items?page=3" {
+		t.Errorf("expected the next link, got '%s'", next)
+	}
+}
+
+type memoryCache map[string]CachedResponse
+
+func (c memoryCache) Get(url string) (CachedResponse, bool) {
+	response, ok := c[url]
+	return response, ok
+}
+
+func (c memoryCache) Put(url string, response CachedResponse) {
+	c[url] = response
+}
+
+func TestGetAllFollowsCachedLinkOnNotModified(t *testing.T) {
+	notModified := 0
+	srv := pagedServer(t, 3, func(w http.ResponseWriter, r *http.Request, page int) bool {
+		etag := fmt.Sprintf(`"page-%d"`, page)
+		// Like GitHub, 304 responses don't repeat the Link header.
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		w.Header().Set("ETag", etag)
+		return false
+	})
+
+	previous := ResponseCache
+	ResponseCache = memoryCache{}
+	t.Cleanup(func() { ResponseCache = previous })
+
+	for run := 1; run <= 2; run++ {
+		body, err := GetAll(context.Background(), srv.URL+"/items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "[1,2,3]" {
+			t.Errorf("run %d: expected the items of all pages, got %s", run, body)
+		}
+	}
+
+	if notModified != 3 {
+		t.Errorf("expected every page of the second run to be answered with 304, got %d", notModified)
+	}
+}
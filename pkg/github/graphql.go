@@ -0,0 +1,91 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// graphQLURL returns the GraphQL endpoint of APIURL, which is /api/graphql
+// instead of /api/v3/graphql on GitHub Enterprise Server.
+func graphQLURL() string {
+	if strings.HasSuffix(APIURL, "/api/v3") {
+		return strings.TrimSuffix(APIURL, "/v3") + "/graphql"
+	}
+	return APIURL + "/graphql"
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// GraphQL runs query against the GraphQL API and decodes its data into out.
+func GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("Error marshaling graphql request: %w", err)
+	}
+
+	url := graphQLURL()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Error constructing graphql request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	auth, err := authorization(ctx)
+	if err != nil {
+		return err
+	}
+	if auth == "" {
+		return fmt.Errorf("The GraphQL API can't be used anonymously, set GITHUB_TOKEN or GITHUB_APP_ID")
+	}
+	req.Header.Set("Authorization", auth)
+
+	resp, respBytes, err := do(req)
+	if err != nil {
+		return fmt.Errorf("Error sending graphql request: %w", err)
+	}
+
+	err = checkResponse(url, resp, respBytes)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+
+	err = json.Unmarshal(respBytes, &result)
+	if err != nil {
+		return fmt.Errorf("Error parsing graphql response: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		queryErr := &QueryError{}
+		for _, e := range result.Errors {
+			switch e.Type {
+			case "RATE_LIMITED":
+				return &RateLimitError{URL: url, Message: e.Message}
+			case "FORBIDDEN":
+				return &AuthError{URL: url, StatusCode: resp.StatusCode, Message: e.Message}
+			case "NOT_FOUND":
+				return &NotFoundError{URL: url}
+			}
+			queryErr.Types = append(queryErr.Types, e.Type)
+			queryErr.Messages = append(queryErr.Messages, e.Message)
+		}
+		return queryErr
+	}
+
+	err = json.Unmarshal(result.Data, out)
+	if err != nil {
+		return fmt.Errorf("Error parsing graphql data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitUsage is the rate limit budget of one API resource, such as
+// core or graphql, as last reported by GitHub.
+type RateLimitUsage struct {
+	Resource  string    `json:"resource"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	// Consumed is how much of the budget the requests of this process used.
+	Consumed int `json:"consumed"`
+}
+
+// PaceBelow is the fraction of a rate limit below which requests are paced,
+// spreading the remaining budget over the time until the limit resets.
+var PaceBelow = 0.1
+
+// OnThrottle is called before sleeping to stay within a rate limit.
+var OnThrottle func(resource string, wait time.Duration)
+
+var (
+	rateLimitsMu sync.Mutex
+	rateLimits   = map[string]*RateLimitUsage{}
+)
+
+// RateLimits returns the usage of every resource requests were sent to.
+func RateLimits() []RateLimitUsage {
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	usages := []RateLimitUsage{}
+	for _, usage := range rateLimits {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Resource < usages[j].Resource
+	})
+	return usages
+}
+
+// recordRateLimit updates the usage of the resource from the rate limit
+// headers of a response.
+func recordRateLimit(header http.Header) {
+	resource := header.Get("X-RateLimit-Resource")
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if resource == "" || err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	resetUnix, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	reset := time.Unix(resetUnix, 0)
+
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	usage, ok := rateLimits[resource]
+	switch {
+	case !ok:
+		// What was used before this process started is unknown, a request
+		// costs at least 1.
+		usage = &RateLimitUsage{Resource: resource, Consumed: 1}
+		rateLimits[resource] = usage
+	case !reset.Equal(usage.Reset):
+		usage.Consumed += used
+	case limit-remaining > usage.Limit-usage.Remaining:
+		usage.Consumed += limit - remaining - (usage.Limit - usage.Remaining)
+	}
+	usage.Limit = limit
+	usage.Remaining = remaining
+	usage.Reset = reset
+}
+
+// throttle waits before a request to the resource if its budget is nearly
+// exhausted, until the limit resets when nothing is left.
+func throttle(ctx context.Context, resource string) error {
+	rateLimitsMu.Lock()
+	usage, ok := rateLimits[resource]
+	var wait time.Duration
+	if ok && float64(usage.Remaining) < PaceBelow*float64(usage.Limit) {
+		untilReset := time.Until(usage.Reset)
+		if untilReset > 0 {
+			if usage.Remaining > 0 {
+				wait = untilReset / time.Duration(usage.Remaining)
+			} else {
+				// Give the reset a second of slack for clock drift.
+				wait = untilReset + time.Second
+			}
+		}
+	}
+	rateLimitsMu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if OnThrottle != nil {
+		OnThrottle(resource, wait)
+	}
+	return sleep(ctx, wait)
+}
+
+func rateLimitResource(req *http.Request) string {
+	if req.URL.String() == graphQLURL() {
+		return "graphql"
+	}
+	return "core"
+}
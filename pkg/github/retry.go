@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// MaxAttempts is how often a request is sent before a transient failure is
+// returned. Connection errors, timeouts and 5xx responses are retried.
+var MaxAttempts = 3
+
+// RetryDelay is the backoff before the first retry. It doubles with every
+// further attempt, plus up to 50% jitter.
+var RetryDelay = time.Second
+
+// RequestTimeout limits every single attempt of a request, 0 disables it.
+// Attempts that time out are retried.
+var RequestTimeout = time.Minute
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do sends req, retrying transient failures, and returns the response with
+// its body already read.
+func do(req *http.Request) (*http.Response, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		resp, body, err := send(req)
+		if attempt >= MaxAttempts || req.Context().Err() != nil || !retryable(resp, err) {
+			return resp, body, err
+		}
+
+		delay := RetryDelay << (attempt - 1)
+		if delay > 0 {
+			delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		}
+		err = sleep(req.Context(), delay)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error fetching url '%s': %w", req.URL, err)
+		}
+
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("Error resetting request body for retry: %w", err)
+			}
+		}
+	}
+}
+
+func send(req *http.Request) (*http.Response, []byte, error) {
+	err := throttle(req.Context(), rateLimitResource(req))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching url '%s': %w", req.URL, err)
+	}
+
+	attempt := req
+	if RequestTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), RequestTimeout)
+		defer cancel()
+		attempt = req.WithContext(ctx)
+	}
+
+	resp, err := Client.Do(attempt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error fetching url '%s': %w", req.URL, err)
+	}
+	defer resp.Body.Close()
+	recordRateLimit(resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading response bytes: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// retryServer answers requests with handle, which gets the number of the
+// attempt, and makes retries fast.
+func retryServer(t *testing.T, maxAttempts int, handle func(w http.ResponseWriter, r *http.Request, attempt int)) (*httptest.Server, *int) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		handle(w, r, attempts)
+	}))
+	t.Cleanup(srv.Close)
+
+	previousURL, previousAttempts, previousDelay := APIURL, MaxAttempts, RetryDelay
+	APIURL, MaxAttempts, RetryDelay = srv.URL, maxAttempts, time.Millisecond
+	t.Cleanup(func() { APIURL, MaxAttempts, RetryDelay = previousURL, previousAttempts, previousDelay })
+	t.Setenv("GITHUB_TOKEN", "test")
+
+	return srv, &attempts
+}
+
+func TestGetRetriesServerErrors(t *testing.T) {
+	srv, attempts := retryServer(t, 3, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	})
+
+	body, err := Get(context.Background(), srv.URL+"/teams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("expected the body of the successful attempt, got %s", body)
+	}
+	if *attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", *attempts)
+	}
+}
+
+func TestGetRetriesConnectionErrors(t *testing.T) {
+	srv, attempts := retryServer(t, 3, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	body, err := Get(context.Background(), srv.URL+"/teams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `[]` {
+		t.Errorf("expected the body of the successful attempt, got %s", body)
+	}
+	if *attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", *attempts)
+	}
+}
+
+func TestGetGivesUpAfterMaxAttempts(t *testing.T) {
+	srv, attempts := retryServer(t, 2, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message":"Server Error"}`))
+	})
+
+	_, err := Get(context.Background(), srv.URL+"/teams")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a status error for 500, got %v", err)
+	}
+	if *attempts != 2 {
+		t.Errorf("expected MaxAttempts attempts, got %d", *attempts)
+	}
+}
+
+func TestGetDoesNotRetrySecondaryRateLimits(t *testing.T) {
+	cases := map[string]func(w http.ResponseWriter){
+		"403 with Retry-After": func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusForbidden)
+		},
+		"429 with Retry-After": func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	}
+	for name, respond := range cases {
+		respond := respond
+		t.Run(name, func(t *testing.T) {
+			srv, attempts := retryServer(t, 3, func(w http.ResponseWriter, r *http.Request, attempt int) {
+				respond(w)
+				w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			})
+
+			start := time.Now()
+			_, err := Get(context.Background(), srv.URL+"/teams")
+			var rateLimitErr *RateLimitError
+			if !errors.As(err, &rateLimitErr) {
+				t.Fatalf("expected a rate limit error, got %v", err)
+			}
+			if rateLimitErr.Reset.Before(start.Add(60*time.Second)) || rateLimitErr.Reset.After(time.Now().Add(60*time.Second)) {
+				t.Errorf("expected the reset in 60s, got %s", rateLimitErr.Reset)
+			}
+			if rateLimitErr.Message != "You have exceeded a secondary rate limit." {
+				t.Errorf("expected the message of GitHub, got '%s'", rateLimitErr.Message)
+			}
+			if *attempts != 1 {
+				t.Errorf("expected a single attempt, got %d", *attempts)
+			}
+		})
+	}
+}
+
+func TestGetDoesNotRetryForbidden(t *testing.T) {
+	srv, attempts := retryServer(t, 3, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	})
+
+	_, err := Get(context.Background(), srv.URL+"/teams")
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+	if *attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", *attempts)
+	}
+}
+
+func TestGetStopsRetryingWhenCanceled(t *testing.T) {
+	srv, attempts := retryServer(t, 3, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	RetryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := Get(ctx, srv.URL+"/teams")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+	if *attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", *attempts)
+	}
+}
@@ -0,0 +1,138 @@
+// Package graphalg has traversal helpers for the org graph. They work on
+// anything that can list the neighbors of a node, and treat nodes as
+// connected regardless of edge direction if Neighbors does.
+package graphalg
+
+// Graph is the view of a graph the algorithms need. Neighbors should return
+// nodes in a stable order so results are deterministic.
+type Graph interface {
+	Neighbors(node string) []string
+}
+
+// BFS visits nodes in breadth-first order starting at start, which is
+// visited at depth 0. Returning false from visit stops the search.
+func BFS(g Graph, start string, visit func(node string, depth int) bool) {
+	depths := map[string]int{start: 0}
+	queue := []string{start}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		if !visit(node, depths[node]) {
+			return
+		}
+
+		for _, next := range g.Neighbors(node) {
+			if _, seen := depths[next]; !seen {
+				depths[next] = depths[node] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// ShortestPath returns a path with the fewest hops from from to to,
+// including both ends, or nil if to can't be reached.
+func ShortestPath(g Graph, from, to string) []string {
+	previous := map[string]string{}
+	found := false
+
+	BFS(g, from, func(node string, depth int) bool {
+		if node == to {
+			found = true
+			return false
+		}
+		for _, next := range g.Neighbors(node) {
+			if _, seen := previous[next]; !seen && next != from {
+				previous[next] = node
+			}
+		}
+		return true
+	})
+
+	if !found {
+		return nil
+	}
+
+	path := []string{to}
+	for node := to; node != from; {
+		node = previous[node]
+		path = append([]string{node}, path...)
+	}
+	return path
+}
+
+// Reachable returns every node that can be reached from start within
+// maxDepth hops, excluding start itself, in breadth-first order. A negative
+// maxDepth means no limit.
+func Reachable(g Graph, start string, maxDepth int) []string {
+	reachable := []string{}
+
+	BFS(g, start, func(node string, depth int) bool {
+		if maxDepth >= 0 && depth > maxDepth {
+			return false
+		}
+		if node != start {
+			reachable = append(reachable, node)
+		}
+		return true
+	})
+
+	return reachable
+}
+
+// Connected reports whether there is a path between a and b.
+func Connected(g Graph, a, b string) bool {
+	return ShortestPath(g, a, b) != nil
+}
+
+// WeightedEdge is an undirected edge between A and B.
+type WeightedEdge struct {
+	A, B   string
+	Weight int
+}
+
+// weightedGraph is the graph of the edges heavier than min.
+type weightedGraph struct {
+	edges []WeightedEdge
+	min   int
+}
+
+func (g weightedGraph) Neighbors(node string) []string {
+	neighbors := []string{}
+	seen := map[string]bool{}
+	for _, e := range g.edges {
+		if e.Weight <= g.min {
+			continue
+		}
+		var other string
+		switch node {
+		case e.A:
+			other = e.B
+		case e.B:
+			other = e.A
+		default:
+			continue
+		}
+		if !seen[other] {
+			seen[other] = true
+			neighbors = append(neighbors, other)
+		}
+	}
+	return neighbors
+}
+
+// ImpliedEdges returns the indices of the edges whose ends are also
+// connected by a path of strictly heavier edges. Removing all of them keeps
+// those paths, as an edge on such a path is only implied by an even heavier
+// one. What remains is a maximum spanning forest, except for ties.
+func ImpliedEdges(edges []WeightedEdge) []int {
+	implied := []int{}
+	for i, e := range edges {
+		if e.A != e.B && Connected(weightedGraph{edges: edges, min: e.Weight}, e.A, e.B) {
+			implied = append(implied, i)
+		}
+	}
+	return implied
+}